@@ -0,0 +1,16 @@
+package main
+
+import "strings"
+
+// logFormats lists the web server log formats accepted by -log-format. Both
+// start with the client address, which is all cidrcalc reads.
+var logFormats = []string{"common", "combined"}
+
+// logClientAddress returns the client address of a common or combined log
+// line, such as 192.168.1.1 in:
+//
+//	192.168.1.1 - - [15/Oct/2026:10:00:00 +0000] "GET / HTTP/1.1" 200 512
+func logClientAddress(line string) (string, bool) {
+	addr, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+	return addr, addr != ""
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLogClientAddress(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "common",
+			line:   `192.168.1.10 - - [15/Oct/2026:10:00:00 +0000] "GET / HTTP/1.1" 200 512`,
+			want:   "192.168.1.10",
+			wantOK: true,
+		},
+		{
+			name:   "combined with a user",
+			line:   `2001:db8::7 - alice [15/Oct/2026:10:00:01 +0000] "GET /a HTTP/2.0" 404 0 "-" "curl/8.0"`,
+			want:   "2001:db8::7",
+			wantOK: true,
+		},
+		{
+			name: "blank line",
+			line: "   ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := logClientAddress(tt.line)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("logClientAddress() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRunLogFormat(t *testing.T) {
+	log := `192.168.1.10 - - [15/Oct/2026:10:00:00 +0000] "GET / HTTP/1.1" 200 512 "-" "Mozilla/5.0"
+192.168.1.77 - - [15/Oct/2026:10:00:02 +0000] "GET /favicon.ico HTTP/1.1" 404 0 "http://example.com/" "Mozilla/5.0"
+crawler.example.com - - [15/Oct/2026:10:00:03 +0000] "GET /robots.txt HTTP/1.1" 200 10 "-" "bot"
+192.168.1.130 - bob [15/Oct/2026:10:00:05 +0000] "POST /login HTTP/1.1" 302 0 "-" "Mozilla/5.0"
+`
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-log-format", "combined"}, strings.NewReader(log), &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+	if want := "192.168.1.0/24\n"; stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRunLogFormatUnknown(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-log-format", "json"}, strings.NewReader(""), &stdout, &stderr)
+	if code != ExitBadArgs {
+		t.Errorf("run() = %d, want %d", code, ExitBadArgs)
+	}
+}
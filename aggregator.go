@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+)
+
+// Aggregator computes the smallest block enclosing a stream of addresses
+// added one at a time. Only the lowest and highest addresses are kept, so
+// callers can aggregate any number of addresses without building a slice.
+type Aggregator struct {
+	min, max net.IP
+	mixed    bool
+}
+
+// NewAggregator returns an empty Aggregator.
+func NewAggregator() *Aggregator {
+	return &Aggregator{}
+}
+
+// Add widens the enclosing block to include ip. IPv4 and IPv6 addresses can't
+// be aggregated together; mixing them makes Result fail until Reset.
+func (a *Aggregator) Add(ip net.IP) {
+	ip = normalizeIP(ip)
+	if ip == nil {
+		return
+	}
+	if a.min == nil {
+		a.min, a.max = ip, ip
+		return
+	}
+	if len(ip) != len(a.min) {
+		a.mixed = true
+		return
+	}
+	if bytes.Compare(ip, a.min) < 0 {
+		a.min = ip
+	}
+	if bytes.Compare(ip, a.max) > 0 {
+		a.max = ip
+	}
+}
+
+// Result returns the smallest block enclosing all the addresses added since
+// the Aggregator was created or last reset.
+func (a *Aggregator) Result() (*net.IPNet, error) {
+	if a.min == nil {
+		return nil, errors.New("no IPs provided")
+	}
+	if a.mixed {
+		return nil, errors.New("cannot aggregate IPv4 and IPv6 addresses together")
+	}
+
+	mask, err := maskForPrefix(commonPrefixBits(a.min, a.max), 8*len(a.min))
+	if err != nil {
+		return nil, err
+	}
+	return &net.IPNet{IP: a.min.Mask(mask), Mask: mask}, nil
+}
+
+// Reset forgets all the addresses added so far.
+func (a *Aggregator) Reset() {
+	*a = Aggregator{}
+}
+
+// Result describes the block enclosing a set of addresses.
+type Result struct {
+	CIDR      *net.IPNet
+	Network   net.IP
+	Broadcast net.IP
+	Prefix    int
+
+	// Hosts is the number of usable host addresses of IPv4 blocks. It is 0
+	// for IPv6 blocks.
+	Hosts uint64
+
+	// Skipped lists the inputs that are neither an IP nor a CIDR.
+	Skipped []string
+}
+
+// AggregateStrings returns the smallest block enclosing the given addresses,
+// written as IPs or CIDRs. Invalid inputs are skipped and listed in the
+// result; the error is only returned when no valid address is left or when
+// IPv4 and IPv6 addresses are mixed.
+func AggregateStrings(ips []string) (Result, error) {
+	var result Result
+	agg := NewAggregator()
+	for _, s := range ips {
+		parsed, ok := parseAddress(strings.TrimSpace(s), parseOptions{})
+		if !ok {
+			result.Skipped = append(result.Skipped, s)
+			continue
+		}
+		for _, ip := range parsed {
+			agg.Add(ip)
+		}
+	}
+
+	ipnet, err := agg.Result()
+	if err != nil {
+		return result, err
+	}
+	prefixLen, bits := ipnet.Mask.Size()
+	result.CIDR = ipnet
+	result.Network = ipnet.IP
+	result.Broadcast = broadcastIP(ipnet)
+	result.Prefix = prefixLen
+	if bits == 32 {
+		result.Hosts = usableHosts(prefixLen)
+	}
+	return result, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"net"
+	"slices"
+	"testing"
+)
+
+func TestAggregator(t *testing.T) {
+	steps := []struct {
+		add     string
+		want    string
+		wantErr bool
+	}{
+		{add: "192.168.1.10", want: "192.168.1.10/32"},
+		{add: "192.168.1.11", want: "192.168.1.10/31"},
+		{add: "192.168.1.10", want: "192.168.1.10/31"},
+		{add: "192.168.1.9", want: "192.168.1.8/30"},
+		{add: "192.168.1.200", want: "192.168.1.0/24"},
+		{add: "10.0.0.1", want: "0.0.0.0/0"},
+		{add: "2001:db8::1", wantErr: true},
+	}
+
+	agg := NewAggregator()
+	if _, err := agg.Result(); err == nil {
+		t.Error("Result() on an empty Aggregator: error = nil, want an error")
+	}
+
+	for _, step := range steps {
+		agg.Add(net.ParseIP(step.add))
+		got, err := agg.Result()
+		if (err != nil) != step.wantErr {
+			t.Fatalf("after Add(%s): Result() error = %v, wantErr %v", step.add, err, step.wantErr)
+		}
+		if !step.wantErr && got.String() != step.want {
+			t.Errorf("after Add(%s): Result() = %v, want %v", step.add, got, step.want)
+		}
+	}
+
+	agg.Reset()
+	if _, err := agg.Result(); err == nil {
+		t.Error("Result() after Reset: error = nil, want an error")
+	}
+
+	agg.Add(net.ParseIP("2001:db8::1"))
+	agg.Add(net.ParseIP("2001:db8::ff"))
+	got, err := agg.Result()
+	if err != nil {
+		t.Fatalf("Result() error = %v", err)
+	}
+	if got.String() != "2001:db8::/120" {
+		t.Errorf("Result() = %v, want 2001:db8::/120", got)
+	}
+}
+
+func TestAggregatorMatchesCalculateCIDR(t *testing.T) {
+	ips := []net.IP{
+		net.ParseIP("172.16.5.4"),
+		net.ParseIP("172.16.0.1"),
+		net.ParseIP("172.16.9.200"),
+	}
+
+	agg := NewAggregator()
+	for _, ip := range ips {
+		agg.Add(ip)
+	}
+	got, err := agg.Result()
+	if err != nil {
+		t.Fatalf("Result() error = %v", err)
+	}
+	want, err := calculateCIDR(ips)
+	if err != nil {
+		t.Fatalf("calculateCIDR() error = %v", err)
+	}
+	if got.String() != want {
+		t.Errorf("Result() = %v, calculateCIDR() = %v", got, want)
+	}
+}
+
+func TestAggregateStrings(t *testing.T) {
+	tests := []struct {
+		name          string
+		ips           []string
+		wantCIDR      string
+		wantBroadcast string
+		wantHosts     uint64
+		wantSkipped   []string
+		wantErr       bool
+	}{
+		{
+			name:          "IPs and CIDRs",
+			ips:           []string{"192.168.1.1", " 192.168.1.9 ", "192.168.1.16/30"},
+			wantCIDR:      "192.168.1.0/27",
+			wantBroadcast: "192.168.1.31",
+			wantHosts:     30,
+		},
+		{
+			name:          "invalid strings are skipped",
+			ips:           []string{"10.0.0.1", "not-an-ip", "10.0.0.2", "10.0.0.300", ""},
+			wantCIDR:      "10.0.0.0/30",
+			wantBroadcast: "10.0.0.3",
+			wantHosts:     2,
+			wantSkipped:   []string{"not-an-ip", "10.0.0.300", ""},
+		},
+		{
+			name:          "IPv6",
+			ips:           []string{"2001:db8::1", "2001:db8::ff"},
+			wantCIDR:      "2001:db8::/120",
+			wantBroadcast: "2001:db8::ff",
+		},
+		{
+			name:        "nothing valid",
+			ips:         []string{"nope"},
+			wantSkipped: []string{"nope"},
+			wantErr:     true,
+		},
+		{
+			name:    "mixed families",
+			ips:     []string{"10.0.0.1", "2001:db8::1"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AggregateStrings(tt.ips)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AggregateStrings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got.Skipped, tt.wantSkipped) {
+				t.Errorf("AggregateStrings() Skipped = %q, want %q", got.Skipped, tt.wantSkipped)
+			}
+			if err != nil {
+				return
+			}
+			if got.CIDR.String() != tt.wantCIDR {
+				t.Errorf("AggregateStrings() CIDR = %v, want %v", got.CIDR, tt.wantCIDR)
+			}
+			prefix, _ := got.CIDR.Mask.Size()
+			if got.Prefix != prefix || !got.Network.Equal(got.CIDR.IP) {
+				t.Errorf("AggregateStrings() Prefix = %d and Network = %v, want %d and %v", got.Prefix, got.Network, prefix, got.CIDR.IP)
+			}
+			if got.Broadcast.String() != tt.wantBroadcast {
+				t.Errorf("AggregateStrings() Broadcast = %v, want %v", got.Broadcast, tt.wantBroadcast)
+			}
+			if got.Hosts != tt.wantHosts {
+				t.Errorf("AggregateStrings() Hosts = %d, want %d", got.Hosts, tt.wantHosts)
+			}
+		})
+	}
+}
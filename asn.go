@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// asnPrefix is a prefix announced by an autonomous system.
+type asnPrefix struct {
+	ipnet *net.IPNet
+	asn   uint32
+}
+
+// asnTable attributes IPs to the autonomous system announcing the most
+// specific prefix holding them. Its prefixes are sorted longest first.
+type asnTable []asnPrefix
+
+// readASNMap reads an ASN map with one "CIDR ASN" pair per line, such as
+// "192.0.2.0/24 64496" or "192.0.2.0/24 AS64496". Blank lines are ignored and
+// "#" starts a comment.
+func readASNMap(reader io.Reader) (asnTable, error) {
+	var table asnTable
+	scanner := bufio.NewScanner(reader)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: want a CIDR and an ASN, got %q", lineNum, strings.TrimSpace(line))
+		}
+
+		_, ipnet, err := parseCIDR(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		asn, err := parseASN(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		table = append(table, asnPrefix{ipnet: ipnet, asn: asn})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(table, func(i, j int) bool {
+		iOnes, _ := table[i].ipnet.Mask.Size()
+		jOnes, _ := table[j].ipnet.Mask.Size()
+		return iOnes > jOnes
+	})
+	return table, nil
+}
+
+// loadASNMap reads the ASN map stored in the file at path.
+func loadASNMap(path string) (asnTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readASNMap(f)
+}
+
+// parseASN parses an AS number with or without its "AS" prefix.
+func parseASN(s string) (uint32, error) {
+	asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(s), "AS"), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ASN %q", s)
+	}
+	return uint32(asn), nil
+}
+
+// lookup returns the AS announcing the most specific prefix holding ip.
+func (t asnTable) lookup(ip net.IP) (uint32, bool) {
+	for _, p := range t {
+		if p.ipnet.Contains(ip) {
+			return p.asn, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadASNMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantLen int
+		wantErr bool
+	}{
+		{name: "plain and prefixed ASNs", input: "10.0.0.0/8 64496\n10.1.0.0/16 AS64497\n", wantLen: 2},
+		{name: "comments and blank lines", input: "# map\n\n10.0.0.0/8 64496 # trailing\n", wantLen: 1},
+		{name: "missing ASN", input: "10.0.0.0/8\n", wantErr: true},
+		{name: "invalid CIDR", input: "10.0.0.0/33 64496\n", wantErr: true},
+		{name: "invalid ASN", input: "10.0.0.0/8 ASx\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := readASNMap(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readASNMap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(table) != tt.wantLen {
+				t.Errorf("readASNMap() returned %d prefixes, want %d", len(table), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestASNTableLookup(t *testing.T) {
+	table, err := readASNMap(strings.NewReader("10.0.0.0/8 64496\n10.1.0.0/16 64497\n192.168.0.0/16 64498\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip     string
+		want   uint32
+		wantOK bool
+	}{
+		{ip: "10.2.0.1", want: 64496, wantOK: true},
+		{ip: "10.1.0.1", want: 64497, wantOK: true},
+		{ip: "192.168.1.1", want: 64498, wantOK: true},
+		{ip: "172.16.0.1", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			got, ok := table.lookup(net.ParseIP(tt.ip))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("lookup() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRunASNFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "asn.txt")
+	if err := os.WriteFile(path, []byte("10.0.0.0/8 64496\n10.1.0.0/16 64497\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		asn        string
+		wantStdout string
+	}{
+		{asn: "64496", wantStdout: "10.0.0.0/14\n"},
+		{asn: "AS64497", wantStdout: "10.1.0.0/31\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.asn, func(t *testing.T) {
+			stdin := strings.NewReader("10.0.0.1\n10.1.0.0\n10.1.0.1\n10.2.0.1\n172.16.0.1\n")
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"-asn-map", path, "-asn", tt.asn}, stdin, &stdout, &stderr)
+			if code != ExitOK {
+				t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
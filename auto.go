@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Input formats recognized by -auto.
+const (
+	formatIP      = "ip"
+	formatCIDR    = "cidr"
+	formatJSON    = "json"
+	formatInteger = "integer"
+)
+
+// detectLineFormat guesses the format of an input line.
+func detectLineFormat(line string) string {
+	switch {
+	case strings.HasPrefix(line, "{"):
+		return formatJSON
+	case strings.Contains(line, "/"):
+		return formatCIDR
+	case strings.Trim(line, "0123456789") == "":
+		return formatInteger
+	}
+	return formatIP
+}
+
+// sniffFormat detects the format of the input from its first non-empty line.
+// It returns a reader yielding the whole input, including the lines read to
+// detect the format. Empty inputs are reported as formatIP.
+func sniffFormat(reader io.Reader) (string, io.Reader, error) {
+	br := bufio.NewReader(reader)
+	var consumed strings.Builder
+	format := formatIP
+	for {
+		line, err := br.ReadString('\n')
+		consumed.WriteString(line)
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			format = detectLineFormat(trimmed)
+			break
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	return format, io.MultiReader(strings.NewReader(consumed.String()), br), nil
+}
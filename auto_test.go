@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSniffFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "bare IPs", input: "192.168.1.1\n192.168.1.2\n", want: formatIP},
+		{name: "IPv6", input: "2001:db8::1\n", want: formatIP},
+		{name: "CIDRs", input: "192.168.1.0/24\n", want: formatCIDR},
+		{name: "JSON", input: `{"ip":"192.168.1.1"}` + "\n", want: formatJSON},
+		{name: "integers", input: "3232235777\n", want: formatInteger},
+		{name: "leading blank lines", input: "\n  \n192.168.1.0/24\n", want: formatCIDR},
+		{name: "no trailing newline", input: "3232235777", want: formatInteger},
+		{name: "empty", input: "", want: formatIP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, replay, err := sniffFormat(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("sniffFormat() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("sniffFormat() = %q, want %q", got, tt.want)
+			}
+			content, err := io.ReadAll(replay)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.input {
+				t.Errorf("sniffFormat() replayed %q, want %q", content, tt.input)
+			}
+		})
+	}
+}
+
+func TestRunAuto(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "bare IPs",
+			args:       []string{"-auto", "-debug"},
+			stdin:      "192.168.1.1\n192.168.1.2\n",
+			wantStdout: "192.168.1.0/30\n",
+			wantStderr: "Detected input format: ip",
+		},
+		{
+			name:       "CIDRs",
+			args:       []string{"-auto", "-debug"},
+			stdin:      "192.168.1.0/25\n192.168.1.128/25\n",
+			wantStdout: "192.168.1.0/24\n",
+			wantStderr: "Detected input format: cidr",
+		},
+		{
+			name:       "JSON",
+			args:       []string{"-auto", "-debug"},
+			stdin:      `{"ip":"192.168.1.1"}` + "\n" + `{"ip":"192.168.1.2"}` + "\n",
+			wantStdout: "192.168.1.0/30\n",
+			wantStderr: "Detected input format: json",
+		},
+		{
+			name:       "integers",
+			args:       []string{"-auto", "-debug"},
+			stdin:      "\n3232235777\n3232235778\n",
+			wantStdout: "192.168.1.0/30\n",
+			wantStderr: "Detected input format: integer",
+		},
+		{
+			name:       "explicit flag wins",
+			args:       []string{"-auto", "-numeric"},
+			stdin:      "3232235777\n",
+			wantStdout: "192.168.1.1/32\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != ExitOK {
+				t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
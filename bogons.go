@@ -0,0 +1,30 @@
+package main
+
+import "net"
+
+// bogons lists the well-known IPv4 ranges that are never routed on the public
+// Internet. They are removed from the result by -drop-bogons.
+var bogons = mustParseCIDRs(
+	"0.0.0.0/8",      // "This" network.
+	"10.0.0.0/8",     // Private use.
+	"127.0.0.0/8",    // Loopback.
+	"169.254.0.0/16", // Link-local.
+	"172.16.0.0/12",  // Private use.
+	"192.168.0.0/16", // Private use.
+	"224.0.0.0/4",    // Multicast.
+	"240.0.0.0/4",    // Reserved.
+)
+
+// mustParseCIDRs parses the given CIDRs and panics if one is invalid. It is
+// meant for tables of well-known ranges.
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = ipnet
+	}
+	return nets
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunDropBogons(t *testing.T) {
+	tests := []struct {
+		name       string
+		stdin      string
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "public and private space",
+			stdin:      "8.8.8.8\n10.0.0.1\n",
+			wantStdout: "8.0.0.0/7\n11.0.0.0/8\n",
+		},
+		{
+			name:       "public block around 172.16/12",
+			stdin:      "172.0.0.1\n172.31.255.255\n172.63.0.1\n",
+			wantStdout: "172.0.0.0/12\n172.32.0.0/11\n",
+		},
+		{
+			name:       "only bogons",
+			stdin:      "192.168.1.1\n192.168.1.2\n",
+			wantStdout: "",
+			wantStderr: "No routable blocks left in 192.168.1.0/30 after dropping bogons.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"-drop-bogons"}, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != ExitOK {
+				t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
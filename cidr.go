@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// parseCIDR is like net.ParseCIDR but its errors tell what is wrong with s:
+// the address, the prefix or the missing slash.
+func parseCIDR(s string) (net.IP, *net.IPNet, error) {
+	addr, prefix, ok := strings.Cut(s, "/")
+	if !ok {
+		return nil, nil, fmt.Errorf("missing /prefix in %q", s)
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil, nil, fmt.Errorf("invalid address %q in %q", addr, s)
+	}
+	family, bits := "IPv4", 8*net.IPv4len
+	if strings.Contains(addr, ":") {
+		family, bits = "IPv6", 8*net.IPv6len
+	}
+	prefixLen, err := strconv.Atoi(prefix)
+	if err != nil || strings.Trim(prefix, "0123456789") != "" {
+		return nil, nil, fmt.Errorf("invalid prefix /%s in %q", prefix, s)
+	}
+	if prefixLen > bits {
+		return nil, nil, fmt.Errorf("prefix /%d out of range for %s", prefixLen, family)
+	}
+	return net.ParseCIDR(s)
+}
+
+// maskToPrefix returns the prefix length of a dotted IPv4 netmask such as
+// 255.255.255.0. The mask must be contiguous: ones followed by zeros.
+func maskToPrefix(mask net.IP) (int, error) {
+	mask4 := mask.To4()
+	if mask4 == nil {
+		return 0, fmt.Errorf("invalid IPv4 mask %s", mask)
+	}
+	ones, bits := net.IPMask(mask4).Size()
+	if bits == 0 {
+		return 0, fmt.Errorf("non-contiguous mask %s", mask)
+	}
+	return ones, nil
+}
+
+// canonicalCIDR returns block with its host bits zeroed, so that its address
+// is the network address, e.g. 192.168.1.0/24 for 192.168.1.57/24.
+func canonicalCIDR(block *net.IPNet) *net.IPNet {
+	return &net.IPNet{IP: block.IP.Mask(block.Mask), Mask: block.Mask}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestParseCIDR(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr string
+	}{
+		{input: "192.168.1.0/24", want: "192.168.1.0/24"},
+		{input: "192.168.1.5/24", want: "192.168.1.0/24"},
+		{input: "2001:db8::/32", want: "2001:db8::/32"},
+		{input: "192.168.1.0/33", wantErr: "prefix /33 out of range for IPv4"},
+		{input: "2001:db8::/129", wantErr: "prefix /129 out of range for IPv6"},
+		{input: "192.168.1.0/", wantErr: `invalid prefix / in "192.168.1.0/"`},
+		{input: "192.168.1.0/-1", wantErr: `invalid prefix /-1 in "192.168.1.0/-1"`},
+		{input: "192.168.1.0/+8", wantErr: `invalid prefix /+8 in "192.168.1.0/+8"`},
+		{input: "192.168.1.0/abc", wantErr: `invalid prefix /abc in "192.168.1.0/abc"`},
+		{input: "192.168.1.256/24", wantErr: `invalid address "192.168.1.256" in "192.168.1.256/24"`},
+		{input: "/24", wantErr: `invalid address "" in "/24"`},
+		{input: "192.168.1.0", wantErr: `missing /prefix in "192.168.1.0"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, ipnet, err := parseCIDR(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("parseCIDR() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCIDR() error = %v", err)
+			}
+			if ipnet.String() != tt.want {
+				t.Errorf("parseCIDR() = %v, want %v", ipnet, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskToPrefix(t *testing.T) {
+	tests := []struct {
+		mask    string
+		want    int
+		wantErr bool
+	}{
+		{mask: "255.255.255.0", want: 24},
+		{mask: "255.255.255.255", want: 32},
+		{mask: "255.255.240.0", want: 20},
+		{mask: "0.0.0.0", want: 0},
+		{mask: "255.0.255.0", wantErr: true},
+		{mask: "255.255.255.1", wantErr: true},
+		{mask: "ffff:ffff::", wantErr: true},
+		{mask: "nope", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mask, func(t *testing.T) {
+			got, err := maskToPrefix(net.ParseIP(tt.mask))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("maskToPrefix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("maskToPrefix() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunMaskInput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("192.168.0.0 255.255.255.0\n192.168.1.0\t255.255.255.128\n192.168.2.0 255.0.255.0\n")
+	code := run([]string{"-mask-input"}, stdin, &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+	if want := "192.168.0.0/23\n"; stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestCanonicalCIDR(t *testing.T) {
+	tests := []struct {
+		ip     string
+		prefix int
+		bits   int
+		want   string
+	}{
+		{ip: "192.168.1.57", prefix: 32, bits: 32, want: "192.168.1.57/32"},
+		{ip: "192.168.1.57", prefix: 31, bits: 32, want: "192.168.1.56/31"},
+		{ip: "192.168.1.57", prefix: 24, bits: 32, want: "192.168.1.0/24"},
+		{ip: "192.168.1.57", prefix: 13, bits: 32, want: "192.168.0.0/13"},
+		{ip: "192.168.1.57", prefix: 0, bits: 32, want: "0.0.0.0/0"},
+		{ip: "2001:db8::1", prefix: 64, bits: 128, want: "2001:db8::/64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			if tt.bits == 32 {
+				ip = ip.To4()
+			}
+			block := &net.IPNet{IP: ip, Mask: net.CIDRMask(tt.prefix, tt.bits)}
+			if got := canonicalCIDR(block).String(); got != tt.want {
+				t.Errorf("canonicalCIDR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"net"
+)
+
+// specialRange is a well-known range of addresses reserved for a purpose.
+type specialRange struct {
+	block    *net.IPNet
+	category string
+}
+
+// specialRanges lists the ranges -classify reports blocks as falling into.
+var specialRanges = []specialRange{
+	{mustParseCIDRs("10.0.0.0/8")[0], "private"},
+	{mustParseCIDRs("127.0.0.0/8")[0], "loopback"},
+	{mustParseCIDRs("169.254.0.0/16")[0], "link-local"},
+	{mustParseCIDRs("172.16.0.0/12")[0], "private"},
+	{mustParseCIDRs("192.0.2.0/24")[0], "documentation"},
+	{mustParseCIDRs("192.168.0.0/16")[0], "private"},
+	{mustParseCIDRs("198.51.100.0/24")[0], "documentation"},
+	{mustParseCIDRs("203.0.113.0/24")[0], "documentation"},
+	{mustParseCIDRs("224.0.0.0/4")[0], "multicast"},
+	{mustParseCIDRs("::1/128")[0], "loopback"},
+	{mustParseCIDRs("fe80::/10")[0], "link-local"},
+	{mustParseCIDRs("fc00::/7")[0], "unique local"},
+	{mustParseCIDRs("2001:db8::/32")[0], "documentation"},
+	{mustParseCIDRs("ff00::/8")[0], "multicast"},
+}
+
+// classifyBlock tells which special range block falls into entirely, if any.
+func classifyBlock(block *net.IPNet) string {
+	for _, r := range specialRanges {
+		if cidrContains(r.block, block) {
+			return fmt.Sprintf("%s is %s (%s)", block, r.category, r.block)
+		}
+	}
+	return fmt.Sprintf("%s is not within a special range", block)
+}
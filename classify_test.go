@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestClassifyBlock(t *testing.T) {
+	tests := []struct {
+		name  string
+		block string
+		want  string
+	}{
+		{name: "IPv4 private", block: "192.168.1.0/24", want: "192.168.1.0/24 is private (192.168.0.0/16)"},
+		{name: "IPv4 documentation", block: "198.51.100.0/25", want: "198.51.100.0/25 is documentation (198.51.100.0/24)"},
+		{name: "IPv4 public", block: "8.8.8.0/24", want: "8.8.8.0/24 is not within a special range"},
+		{name: "IPv4 across ranges", block: "10.0.0.0/7", want: "10.0.0.0/7 is not within a special range"},
+		{name: "IPv6 loopback", block: "::1/128", want: "::1/128 is loopback (::1/128)"},
+		{name: "IPv6 link-local", block: "fe80::/64", want: "fe80::/64 is link-local (fe80::/10)"},
+		{name: "IPv6 unique local", block: "fd12:3456:789a::/48", want: "fd12:3456:789a::/48 is unique local (fc00::/7)"},
+		{name: "IPv6 documentation", block: "2001:db8:1::/48", want: "2001:db8:1::/48 is documentation (2001:db8::/32)"},
+		{name: "IPv6 multicast", block: "ff02::/16", want: "ff02::/16 is multicast (ff00::/8)"},
+		{name: "IPv6 global", block: "2606:4700::/32", want: "2606:4700::/32 is not within a special range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, block, _ := net.ParseCIDR(tt.block)
+			if got := classifyBlock(block); got != tt.want {
+				t.Errorf("classifyBlock() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunClassify(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantStderr string
+	}{
+		{
+			name:       "unique local aggregate",
+			input:      "fd00:1::1\nfd00:1::ff\n",
+			wantStderr: "fd00:1::/120 is unique local (fc00::/7)\n",
+		},
+		{
+			name:       "documentation aggregate",
+			input:      "2001:db8::1\n2001:db8::8:1\n",
+			wantStderr: "2001:db8::/108 is documentation (2001:db8::/32)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"-classify"}, strings.NewReader(tt.input), &stdout, &stderr)
+			if code != ExitOK {
+				t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("run() stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
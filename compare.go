@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+)
+
+// blockSize returns the number of addresses in block.
+func blockSize(block *net.IPNet) *big.Int {
+	ones, bits := block.Mask.Size()
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+}
+
+// compareToReference describes how block relates to the reference block ref:
+// equal to it, narrower or broader and by how many addresses, or disjoint.
+func compareToReference(block, ref *net.IPNet) string {
+	diff := new(big.Int).Sub(blockSize(block), blockSize(ref))
+	switch {
+	case block.String() == ref.String():
+		return fmt.Sprintf("%s is equal to %s", block, ref)
+	case cidrContains(ref, block):
+		return fmt.Sprintf("%s is narrower than %s by %s addresses", block, ref, diff.Neg(diff))
+	case cidrContains(block, ref):
+		return fmt.Sprintf("%s is broader than %s by %s addresses", block, ref, diff)
+	}
+	return fmt.Sprintf("%s is disjoint from %s", block, ref)
+}
+
+// compareStrategies tells how many addresses the single enclosing block of
+// the IPv4 addresses covers next to the blocks printed by -minimal, and by
+// which percentage -minimal reduces the number of covered addresses.
+func compareStrategies(ips []net.IP) (string, error) {
+	single, err := calculateIPNet(ips)
+	if err != nil {
+		return "", err
+	}
+	minimal, err := minimalCIDRs(ips)
+	if err != nil {
+		return "", err
+	}
+
+	singleSize := blockSize(single)
+	minimalSize := new(big.Int)
+	for _, block := range minimal {
+		minimalSize.Add(minimalSize, blockSize(block))
+	}
+	saved := new(big.Float).SetInt(new(big.Int).Sub(singleSize, minimalSize))
+	reduction, _ := saved.Quo(saved, new(big.Float).SetInt(singleSize)).Float64()
+
+	return fmt.Sprintf("single block %s covers %s addresses, -minimal covers %s addresses in %d blocks (%.5g%% fewer)",
+		single, singleSize, minimalSize, len(minimal), reduction*100), nil
+}
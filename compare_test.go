@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestCompareToReference(t *testing.T) {
+	tests := []struct {
+		name  string
+		block string
+		ref   string
+		want  string
+	}{
+		{name: "equal", block: "10.0.0.0/24", ref: "10.0.0.0/24", want: "10.0.0.0/24 is equal to 10.0.0.0/24"},
+		{name: "narrower", block: "10.0.1.0/24", ref: "10.0.0.0/16", want: "10.0.1.0/24 is narrower than 10.0.0.0/16 by 65280 addresses"},
+		{name: "broader", block: "10.0.0.0/23", ref: "10.0.1.0/24", want: "10.0.0.0/23 is broader than 10.0.1.0/24 by 256 addresses"},
+		{name: "disjoint", block: "10.0.0.0/24", ref: "192.168.0.0/16", want: "10.0.0.0/24 is disjoint from 192.168.0.0/16"},
+		{name: "different families", block: "10.0.0.0/24", ref: "2001:db8::/32", want: "10.0.0.0/24 is disjoint from 2001:db8::/32"},
+		{name: "IPv6", block: "2001:db8::/64", ref: "2001:db8::/32", want: "2001:db8::/64 is narrower than 2001:db8::/32 by 79228162495817593519834398720 addresses"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, block, _ := net.ParseCIDR(tt.block)
+			_, ref, _ := net.ParseCIDR(tt.ref)
+			if got := compareToReference(block, ref); got != tt.want {
+				t.Errorf("compareToReference() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareStrategies(t *testing.T) {
+	tests := []struct {
+		name    string
+		ips     []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "sparse input",
+			ips:  []string{"10.0.0.1", "10.0.255.254"},
+			want: "single block 10.0.0.0/16 covers 65536 addresses, -minimal covers 2 addresses in 2 blocks (99.997% fewer)",
+		},
+		{
+			name: "half full",
+			ips:  []string{"10.0.0.0", "10.0.0.1", "10.0.0.3"},
+			want: "single block 10.0.0.0/30 covers 4 addresses, -minimal covers 3 addresses in 2 blocks (25% fewer)",
+		},
+		{
+			name: "full block",
+			ips:  []string{"10.0.0.0", "10.0.0.1"},
+			want: "single block 10.0.0.0/31 covers 2 addresses, -minimal covers 2 addresses in 1 blocks (0% fewer)",
+		},
+		{
+			name:    "IPv6",
+			ips:     []string{"2001:db8::1", "2001:db8::2"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ips []net.IP
+			for _, s := range tt.ips {
+				ips = append(ips, normalizeIP(net.ParseIP(s)))
+			}
+			got, err := compareStrategies(ips)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compareStrategies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("compareStrategies() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import "net"
+
+// bulkContains reports, for each of ips, whether it belongs to block. For IPv4
+// blocks, the addresses are checked by comparing masked uint32s, which is much
+// cheaper than calling block.Contains for each one of millions of addresses.
+func bulkContains(block *net.IPNet, ips []net.IP) []bool {
+	result := make([]bool, len(ips))
+
+	network := block.IP.To4()
+	if network == nil || len(block.Mask) != net.IPv4len {
+		for i, ip := range ips {
+			result[i] = block.Contains(ip)
+		}
+		return result
+	}
+
+	mask := ipToUint32(net.IP(block.Mask))
+	base := ipToUint32(network) & mask
+	for i, ip := range ips {
+		ip4 := ip.To4()
+		result[i] = ip4 != nil && ipToUint32(ip4)&mask == base
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math/rand"
+	"net"
+	"testing"
+)
+
+func TestBulkContains(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ips := []net.IP{
+		net.ParseIP("192.168.1.0"),
+		net.ParseIP("192.168.1.255"),
+		net.ParseIP("192.168.2.0"),
+		net.ParseIP("0.0.0.0"),
+		net.ParseIP("255.255.255.255"),
+		net.ParseIP("2001:db8::1"),
+		net.ParseIP("::ffff:192.168.1.7"),
+	}
+	for i := 0; i < 1000; i++ {
+		ips = append(ips, uint32ToIP(0xc0a80000|r.Uint32()&0x0000ffff))
+		ips = append(ips, uint32ToIP(r.Uint32()))
+	}
+
+	for _, cidr := range []string{"192.168.1.0/24", "192.168.0.0/16", "10.0.0.0/8", "192.168.1.7/32", "0.0.0.0/0", "2001:db8::/32"} {
+		t.Run(cidr, func(t *testing.T) {
+			_, block, err := net.ParseCIDR(cidr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := bulkContains(block, ips)
+			if len(got) != len(ips) {
+				t.Fatalf("bulkContains() returned %d results, want %d", len(got), len(ips))
+			}
+			for i, ip := range ips {
+				if want := block.Contains(ip); got[i] != want {
+					t.Errorf("bulkContains() for %v = %v, want %v", ip, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func benchmarkIPs(n int) []net.IP {
+	r := rand.New(rand.NewSource(1))
+	ips := make([]net.IP, n)
+	for i := range ips {
+		ips[i] = uint32ToIP(r.Uint32())
+	}
+	return ips
+}
+
+func BenchmarkBulkContains(b *testing.B) {
+	_, block, _ := net.ParseCIDR("128.0.0.0/2")
+	ips := benchmarkIPs(100000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bulkContains(block, ips)
+	}
+}
+
+func BenchmarkIPNetContains(b *testing.B) {
+	_, block, _ := net.ParseCIDR("128.0.0.0/2")
+	ips := benchmarkIPs(100000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result := make([]bool, len(ips))
+		for j, ip := range ips {
+			result[j] = block.Contains(ip)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+)
+
+// blockContext returns the block one level up from block and the two blocks
+// one level down. The parent is nil for a /0 and there are no children for a
+// single-address block.
+func blockContext(block *net.IPNet) (parent *net.IPNet, children []*net.IPNet) {
+	ones, bits := block.Mask.Size()
+	ip := block.IP.To16()
+	if bits == 32 {
+		ip = block.IP.To4()
+	}
+
+	if ones > 0 {
+		mask := net.CIDRMask(ones-1, bits)
+		parent = &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	}
+	if ones < bits {
+		mask := net.CIDRMask(ones+1, bits)
+		lower := ip.Mask(mask)
+		upper := append(net.IP(nil), lower...)
+		upper[ones/8] |= 0x80 >> (ones % 8)
+		children = []*net.IPNet{{IP: lower, Mask: mask}, {IP: upper, Mask: mask}}
+	}
+	return parent, children
+}
+
+// writeContext prints the parent and children of block, one per line.
+func writeContext(w io.Writer, block *net.IPNet) error {
+	parent, children := blockContext(block)
+	if parent != nil {
+		if _, err := fmt.Fprintf(w, "parent: %s\n", parent); err != nil {
+			return err
+		}
+	}
+	for _, child := range children {
+		if _, err := fmt.Fprintf(w, "child: %s\n", child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBlocksWithContext prints each block followed by its context.
+func writeBlocksWithContext(w io.Writer, blocks []*net.IPNet, opts outputOptions) error {
+	if opts.canonical {
+		blocks = canonicalCIDRs(blocks)
+	}
+	for _, block := range blocks {
+		if err := writeBlock(w, block, opts); err != nil {
+			return err
+		}
+		if err := writeContext(w, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestBlockContext(t *testing.T) {
+	tests := []struct {
+		block        string
+		wantParent   string
+		wantChildren []string
+	}{
+		{
+			block:        "192.168.1.0/24",
+			wantParent:   "192.168.0.0/23",
+			wantChildren: []string{"192.168.1.0/25", "192.168.1.128/25"},
+		},
+		{
+			block:        "10.0.0.5/32",
+			wantParent:   "10.0.0.4/31",
+			wantChildren: nil,
+		},
+		{
+			block:        "0.0.0.0/0",
+			wantParent:   "",
+			wantChildren: []string{"0.0.0.0/1", "128.0.0.0/1"},
+		},
+		{
+			block:        "10.0.0.0/31",
+			wantParent:   "10.0.0.0/30",
+			wantChildren: []string{"10.0.0.0/32", "10.0.0.1/32"},
+		},
+		{
+			block:        "2001:db8::/32",
+			wantParent:   "2001:db8::/31",
+			wantChildren: []string{"2001:db8::/33", "2001:db8:8000::/33"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.block, func(t *testing.T) {
+			_, block, err := net.ParseCIDR(tt.block)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parent, children := blockContext(block)
+			gotParent := ""
+			if parent != nil {
+				gotParent = parent.String()
+			}
+			if gotParent != tt.wantParent {
+				t.Errorf("blockContext() parent = %q, want %q", gotParent, tt.wantParent)
+			}
+			if len(children) != len(tt.wantChildren) {
+				t.Fatalf("blockContext() children = %v, want %v", children, tt.wantChildren)
+			}
+			for i, want := range tt.wantChildren {
+				if children[i].String() != want {
+					t.Errorf("blockContext() children[%d] = %v, want %v", i, children[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteContext(t *testing.T) {
+	_, block, _ := net.ParseCIDR("192.168.1.0/24")
+	var buf bytes.Buffer
+	if err := writeContext(&buf, block); err != nil {
+		t.Fatalf("writeContext() error = %v", err)
+	}
+	want := "parent: 192.168.0.0/23\nchild: 192.168.1.0/25\nchild: 192.168.1.128/25\n"
+	if buf.String() != want {
+		t.Errorf("writeContext() = %q, want %q", buf.String(), want)
+	}
+}
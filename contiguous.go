@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"slices"
+)
+
+// firstGap returns the first range of addresses of an IPv4 block missing from
+// ips, or false when ips cover the whole block. With allowEdges, the network
+// and broadcast addresses of blocks shorter than /31 may be missing.
+func firstGap(block *net.IPNet, ips []net.IP, allowEdges bool) (start, end uint32, found bool, err error) {
+	ones, bits := block.Mask.Size()
+	if bits != 32 {
+		return 0, 0, false, fmt.Errorf("only IPv4 blocks are supported")
+	}
+	first, last := ipToUint32(block.IP), ipToUint32(broadcastIP(block))
+	if allowEdges && ones < 31 {
+		first, last = first+1, last-1
+	}
+
+	var sorted []uint32
+	for _, ip := range ips {
+		if u := ipToUint32(ip); ip.To4() != nil && u >= first && u <= last {
+			sorted = append(sorted, u)
+		}
+	}
+	slices.Sort(sorted)
+
+	// next is the address expected after the ones seen so far. It is a
+	// uint64 so that it can go past 255.255.255.255.
+	next := uint64(first)
+	for _, u := range sorted {
+		if uint64(u) > next {
+			return uint32(next), u - 1, true, nil
+		}
+		next = max(next, uint64(u)+1)
+	}
+	if next <= uint64(last) {
+		return uint32(next), last, true, nil
+	}
+	return 0, 0, false, nil
+}
+
+// largestGap returns the two consecutive IPv4 addresses of ips, once sorted,
+// that are the furthest apart, along with their distance. It returns false
+// when there are fewer than two distinct IPv4 addresses.
+func largestGap(ips []net.IP) (lo, hi net.IP, gap uint32, found bool) {
+	var sorted []uint32
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			sorted = append(sorted, ipToUint32(ip))
+		}
+	}
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+
+	for i := 1; i < len(sorted); i++ {
+		if d := sorted[i] - sorted[i-1]; !found || d > gap {
+			lo, hi, gap, found = uint32ToIP(sorted[i-1]), uint32ToIP(sorted[i]), d, true
+		}
+	}
+	return lo, hi, gap, found
+}
+
+// calculateCIDRWithGaps returns the block enclosing the given IPv4 addresses
+// along with the smallest set of blocks covering the addresses of that block
+// missing from ips, sorted by address.
+func calculateCIDRWithGaps(ips []net.IP) (*net.IPNet, []net.IPNet, error) {
+	ipnet, err := calculateIPNet(ips)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ipnet.IP.To4() == nil {
+		return nil, nil, fmt.Errorf("only IPv4 addresses are supported")
+	}
+
+	used := make([]uint32, len(ips))
+	for i, ip := range ips {
+		used[i] = ipToUint32(ip)
+	}
+	slices.Sort(used)
+	used = slices.Compact(used)
+
+	var gaps []net.IPNet
+	// next is the first address not known to be used yet. It is a uint64 so
+	// that it can go past 255.255.255.255.
+	next := uint64(ipToUint32(ipnet.IP))
+	for _, u := range used {
+		if uint64(u) > next {
+			gaps = append(gaps, rangeToCIDRs(uint32(next), u-1)...)
+		}
+		next = uint64(u) + 1
+	}
+	if last := ipToUint32(broadcastIP(ipnet)); next <= uint64(last) {
+		gaps = append(gaps, rangeToCIDRs(uint32(next), last)...)
+	}
+	return ipnet, gaps, nil
+}
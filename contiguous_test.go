@@ -0,0 +1,198 @@
+package main
+
+import (
+	"net"
+	"slices"
+	"testing"
+)
+
+func TestFirstGap(t *testing.T) {
+	tests := []struct {
+		name       string
+		cidr       string
+		ips        []string
+		allowEdges bool
+		wantGap    string
+	}{
+		{
+			name: "contiguous",
+			cidr: "10.0.0.0/30",
+			ips:  []string{"10.0.0.2", "10.0.0.0", "10.0.0.3", "10.0.0.1", "10.0.0.1"},
+		},
+		{
+			name:    "single missing address",
+			cidr:    "10.0.0.0/30",
+			ips:     []string{"10.0.0.0", "10.0.0.1", "10.0.0.3"},
+			wantGap: "10.0.0.2-10.0.0.2",
+		},
+		{
+			name:    "first gap is reported",
+			cidr:    "10.0.0.0/29",
+			ips:     []string{"10.0.0.0", "10.0.0.3", "10.0.0.7"},
+			wantGap: "10.0.0.1-10.0.0.2",
+		},
+		{
+			name:    "missing edges",
+			cidr:    "10.0.0.0/30",
+			ips:     []string{"10.0.0.1", "10.0.0.2"},
+			wantGap: "10.0.0.0-10.0.0.0",
+		},
+		{
+			name:       "missing edges allowed",
+			cidr:       "10.0.0.0/30",
+			ips:        []string{"10.0.0.1", "10.0.0.2"},
+			allowEdges: true,
+		},
+		{
+			name:       "gap in the middle with edges allowed",
+			cidr:       "10.0.0.0/29",
+			ips:        []string{"10.0.0.1", "10.0.0.2", "10.0.0.5", "10.0.0.6"},
+			allowEdges: true,
+			wantGap:    "10.0.0.3-10.0.0.4",
+		},
+		{
+			name:    "missing end of the address space",
+			cidr:    "255.255.255.252/30",
+			ips:     []string{"255.255.255.252", "255.255.255.253"},
+			wantGap: "255.255.255.254-255.255.255.255",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, block, _ := net.ParseCIDR(tt.cidr)
+			var ips []net.IP
+			for _, s := range tt.ips {
+				ips = append(ips, net.ParseIP(s))
+			}
+			start, end, found, err := firstGap(block, ips, tt.allowEdges)
+			if err != nil {
+				t.Fatalf("firstGap() error = %v", err)
+			}
+			var got string
+			if found {
+				got = uint32ToIP(start).String() + "-" + uint32ToIP(end).String()
+			}
+			if got != tt.wantGap {
+				t.Errorf("firstGap() = %q, want %q", got, tt.wantGap)
+			}
+		})
+	}
+}
+
+func TestLargestGap(t *testing.T) {
+	tests := []struct {
+		name    string
+		ips     []string
+		wantLo  string
+		wantHi  string
+		wantGap uint32
+	}{
+		{
+			name: "single address",
+			ips:  []string{"10.0.0.1", "10.0.0.1"},
+		},
+		{
+			name:    "consecutive addresses",
+			ips:     []string{"10.0.0.2", "10.0.0.1"},
+			wantLo:  "10.0.0.1",
+			wantHi:  "10.0.0.2",
+			wantGap: 1,
+		},
+		{
+			name:    "outlier",
+			ips:     []string{"10.0.0.9", "10.0.0.1", "192.168.0.1", "10.0.0.3"},
+			wantLo:  "10.0.0.9",
+			wantHi:  "192.168.0.1",
+			wantGap: 0xc0a80001 - 0x0a000009,
+		},
+		{
+			name:    "first of equal gaps",
+			ips:     []string{"10.0.0.1", "10.0.0.5", "10.0.0.9"},
+			wantLo:  "10.0.0.1",
+			wantHi:  "10.0.0.5",
+			wantGap: 4,
+		},
+		{
+			name:    "whole address space",
+			ips:     []string{"255.255.255.255", "0.0.0.0"},
+			wantLo:  "0.0.0.0",
+			wantHi:  "255.255.255.255",
+			wantGap: 0xffffffff,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ips []net.IP
+			for _, s := range tt.ips {
+				ips = append(ips, net.ParseIP(s))
+			}
+			lo, hi, gap, found := largestGap(ips)
+			if found != (tt.wantLo != "") {
+				t.Fatalf("largestGap() found = %v, want %v", found, tt.wantLo != "")
+			}
+			if !found {
+				return
+			}
+			if lo.String() != tt.wantLo || hi.String() != tt.wantHi || gap != tt.wantGap {
+				t.Errorf("largestGap() = %s, %s, %d, want %s, %s, %d", lo, hi, gap, tt.wantLo, tt.wantHi, tt.wantGap)
+			}
+		})
+	}
+}
+
+func TestCalculateCIDRWithGaps(t *testing.T) {
+	tests := []struct {
+		name      string
+		ips       []string
+		wantBlock string
+		wantGaps  []string
+	}{
+		{
+			name:      "sparse /28",
+			ips:       []string{"10.0.0.1", "10.0.0.6", "10.0.0.7", "10.0.0.14"},
+			wantBlock: "10.0.0.0/28",
+			wantGaps:  []string{"10.0.0.0/32", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.8/30", "10.0.0.12/31", "10.0.0.15/32"},
+		},
+		{
+			name:      "full block",
+			ips:       []string{"10.0.0.3", "10.0.0.2", "10.0.0.1", "10.0.0.0", "10.0.0.2"},
+			wantBlock: "10.0.0.0/30",
+		},
+		{
+			name:      "single address",
+			ips:       []string{"10.0.0.1"},
+			wantBlock: "10.0.0.1/32",
+		},
+		{
+			name:      "end of the address space",
+			ips:       []string{"255.255.255.252", "255.255.255.255"},
+			wantBlock: "255.255.255.252/30",
+			wantGaps:  []string{"255.255.255.253/32", "255.255.255.254/32"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ips []net.IP
+			for _, s := range tt.ips {
+				ips = append(ips, net.ParseIP(s))
+			}
+			block, gaps, err := calculateCIDRWithGaps(ips)
+			if err != nil {
+				t.Fatalf("calculateCIDRWithGaps() error = %v", err)
+			}
+			if block.String() != tt.wantBlock {
+				t.Errorf("calculateCIDRWithGaps() block = %v, want %v", block, tt.wantBlock)
+			}
+			var got []string
+			for _, gap := range gaps {
+				got = append(got, gap.String())
+			}
+			if !slices.Equal(got, tt.wantGaps) {
+				t.Errorf("calculateCIDRWithGaps() gaps = %v, want %v", got, tt.wantGaps)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// csvHeader names the columns of the rows printed by writeCSVResult.
+var csvHeader = []string{"cidr", "prefix", "network", "broadcast", "hosts"}
+
+// writeCSVHeader writes the header row of the CSV output to w.
+func writeCSVHeader(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeCSVResult writes the details of an IPv4 block to w as a CSV row with
+// the columns of csvHeader.
+func writeCSVResult(w io.Writer, block *net.IPNet) error {
+	prefixLen, bits := block.Mask.Size()
+	if bits != 32 {
+		return fmt.Errorf("csv output only supports IPv4 blocks")
+	}
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		block.String(),
+		strconv.Itoa(prefixLen),
+		block.IP.String(),
+		broadcastIP(block).String(),
+		strconv.FormatUint(usableHosts(prefixLen), 10),
+	})
+	cw.Flush()
+	return cw.Error()
+}
+
+// parseIPsFromCSV reads the IP addresses found in the given column (0-indexed)
+// of a CSV document. The first row is skipped when header is set. Fields are
+// parsed like input lines, so they may also hold CIDRs.
+func parseIPsFromCSV(reader io.Reader, column int, header bool, opts parseOptions) ([]net.IP, error) {
+	r := csv.NewReader(reader)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+
+	var ips []net.IP
+	for row := 1; ; row++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header && row == 1 {
+			continue
+		}
+		if column >= len(record) {
+			opts.invalid(fmt.Sprintf("Missing column %d on CSV row %d", column, row))
+			continue
+		}
+
+		rowIPs, ok := parseLine(strings.TrimSpace(record[column]), opts)
+		if !ok || !opts.limit.take(len(rowIPs)) {
+			continue
+		}
+		ips = append(ips, rowIPs...)
+	}
+	return ips, nil
+}
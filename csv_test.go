@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseIPsFromCSV(t *testing.T) {
+	input := `host,ip,owner
+web-1,192.168.1.10,alice
+web-2, 192.168.1.20 ,bob
+"db, primary",192.168.1.200,carol
+broken,not-an-ip,dave
+short
+net,10.0.0.0/30,eve
+`
+	tests := []struct {
+		name   string
+		column int
+		header bool
+		want   []string
+	}{
+		{
+			name:   "IP column with header",
+			column: 1,
+			header: true,
+			want:   []string{"192.168.1.10", "192.168.1.20", "192.168.1.200", "10.0.0.0", "10.0.0.3"},
+		},
+		{
+			name:   "IP column without header",
+			column: 1,
+			header: false,
+			want:   []string{"192.168.1.10", "192.168.1.20", "192.168.1.200", "10.0.0.0", "10.0.0.3"},
+		},
+		{
+			name:   "column without IPs",
+			column: 2,
+			header: true,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ips, err := parseIPsFromCSV(strings.NewReader(input), tt.column, tt.header, parseOptions{})
+			if err != nil {
+				t.Fatalf("parseIPsFromCSV() error = %v", err)
+			}
+			if len(ips) != len(tt.want) {
+				t.Fatalf("parseIPsFromCSV() = %v, want %v", ips, tt.want)
+			}
+			for i := range tt.want {
+				if ips[i].String() != tt.want[i] {
+					t.Errorf("parseIPsFromCSV() IP[%d] = %v, want %v", i, ips[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseIPsFromCSVMalformed(t *testing.T) {
+	_, err := parseIPsFromCSV(strings.NewReader("a,\"unterminated\n"), 0, false, parseOptions{})
+	if err == nil {
+		t.Error("parseIPsFromCSV() error = nil, want an error for malformed CSV")
+	}
+}
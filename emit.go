@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// emitFormats lists the formats accepted by -emit.
+var emitFormats = []string{"nftables", "hcl", "iproute", "python", "ansible", "nmap", "bash", "cisco-acl"}
+
+// emitNftables prints the blocks as an nftables named set, ready to be
+// included in a table definition.
+func emitNftables(w io.Writer, blocks []*net.IPNet, name string) error {
+	addrType := "ipv4_addr"
+	if len(blocks) > 0 && blocks[0].IP.To4() == nil {
+		addrType = "ipv6_addr"
+	}
+
+	elements := make([]string, len(blocks))
+	for i, block := range blocks {
+		elements[i] = block.String()
+	}
+
+	// nft rejects an empty elements list, so an empty set has none.
+	var elementsLine string
+	if len(elements) > 0 {
+		elementsLine = fmt.Sprintf("\telements = { %s }\n", strings.Join(elements, ", "))
+	}
+	_, err := fmt.Fprintf(w, "set %s {\n\ttype %s\n\tflags interval\n%s}\n", name, addrType, elementsLine)
+	return err
+}
+
+// emitHCL prints the blocks as a Terraform attribute, e.g. for the ingress
+// rules of an aws_security_group.
+func emitHCL(w io.Writer, blocks []*net.IPNet) error {
+	elements := make([]string, len(blocks))
+	for i, block := range blocks {
+		elements[i] = strconv.Quote(block.String())
+	}
+	_, err := fmt.Fprintf(w, "cidr_blocks = [%s]\n", strings.Join(elements, ", "))
+	return err
+}
+
+// emitIPRoute prints an `ip route` command per block, routing it through the
+// gateway via.
+func emitIPRoute(w io.Writer, blocks []*net.IPNet, via net.IP) error {
+	for _, block := range blocks {
+		if (block.IP.To4() == nil) != (via.To4() == nil) {
+			return fmt.Errorf("gateway %s and block %s are not of the same address family", via, block)
+		}
+		if _, err := fmt.Fprintf(w, "ip route add %s via %s\n", block, via); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitPython prints the blocks as a Python statement building them with the
+// ipaddress module: a single network, or a list of them when there are
+// several.
+func emitPython(w io.Writer, blocks []*net.IPNet) error {
+	elements := make([]string, len(blocks))
+	for i, block := range blocks {
+		elements[i] = fmt.Sprintf("ipaddress.ip_network(%s)", strconv.Quote(block.String()))
+	}
+	if len(elements) == 1 {
+		_, err := fmt.Fprintf(w, "import ipaddress; net = %s\n", elements[0])
+		return err
+	}
+	_, err := fmt.Fprintf(w, "import ipaddress; nets = [%s]\n", strings.Join(elements, ", "))
+	return err
+}
+
+// emitAnsible prints the blocks as a YAML inventory group whose cidr_blocks
+// variable lists them.
+func emitAnsible(w io.Writer, blocks []*net.IPNet, group string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s:\n  vars:\n    cidr_blocks:\n", group)
+	for _, block := range blocks {
+		fmt.Fprintf(&b, "      - %s\n", block)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// emitNmap prints the blocks as a space-separated list of nmap targets.
+func emitNmap(w io.Writer, blocks []*net.IPNet) error {
+	targets := make([]string, len(blocks))
+	for i, block := range blocks {
+		targets[i] = block.String()
+	}
+	_, err := fmt.Fprintln(w, strings.Join(targets, " "))
+	return err
+}
+
+// emitBash prints the blocks as a bash array assignment, ready to be sourced
+// by a shell script.
+func emitBash(w io.Writer, blocks []*net.IPNet) error {
+	elements := make([]string, len(blocks))
+	for i, block := range blocks {
+		elements[i] = shellQuote(block.String())
+	}
+	_, err := fmt.Fprintf(w, "CIDRS=(%s)\n", strings.Join(elements, " "))
+	return err
+}
+
+// shellQuote returns s as a single shell word. Words made of characters the
+// shell gives no special meaning to, such as CIDRs, are left as is.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "0123456789abcdefABCDEF.:/%_-") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// emitCiscoACL prints a Cisco IOS access list entry per block, permitting
+// traffic from it. IOS matches addresses against a wildcard mask, the inverse
+// of the netmask.
+func emitCiscoACL(w io.Writer, blocks []*net.IPNet) error {
+	for _, block := range blocks {
+		mask := net.IP(block.Mask).To4()
+		if mask == nil {
+			return fmt.Errorf("-emit cisco-acl only supports IPv4 blocks")
+		}
+		wildcard := make(net.IP, net.IPv4len)
+		for i := range mask {
+			wildcard[i] = ^mask[i]
+		}
+		if _, err := fmt.Fprintf(w, "permit ip %s %s any\n", block.IP, wildcard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
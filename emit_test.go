@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestEmitNftables(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks []string
+		want   string
+	}{
+		{
+			name:   "single block",
+			blocks: []string{"192.168.1.0/24"},
+			want: `set allowed {
+	type ipv4_addr
+	flags interval
+	elements = { 192.168.1.0/24 }
+}
+`,
+		},
+		{
+			name:   "several blocks",
+			blocks: []string{"10.0.0.0/8", "192.168.1.0/30", "192.168.1.7/32"},
+			want: `set allowed {
+	type ipv4_addr
+	flags interval
+	elements = { 10.0.0.0/8, 192.168.1.0/30, 192.168.1.7/32 }
+}
+`,
+		},
+		{
+			name:   "IPv6",
+			blocks: []string{"2001:db8::/32"},
+			want: `set allowed {
+	type ipv6_addr
+	flags interval
+	elements = { 2001:db8::/32 }
+}
+`,
+		},
+		{
+			name: "no blocks",
+			want: `set allowed {
+	type ipv4_addr
+	flags interval
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := emitNftables(&buf, mustParseCIDRs(tt.blocks...), "allowed"); err != nil {
+				t.Fatalf("emitNftables() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("emitNftables() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEmitHCL(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks []string
+		want   string
+	}{
+		{
+			name:   "single block",
+			blocks: []string{"192.168.1.0/24"},
+			want:   "cidr_blocks = [\"192.168.1.0/24\"]\n",
+		},
+		{
+			name:   "several blocks",
+			blocks: []string{"10.0.0.0/8", "192.168.1.0/30", "192.168.1.7/32"},
+			want:   "cidr_blocks = [\"10.0.0.0/8\", \"192.168.1.0/30\", \"192.168.1.7/32\"]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := emitHCL(&buf, mustParseCIDRs(tt.blocks...)); err != nil {
+				t.Fatalf("emitHCL() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("emitHCL() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEmitIPRoute(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocks  []string
+		via     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "single block",
+			blocks: []string{"192.168.1.0/24"},
+			via:    "10.0.0.1",
+			want:   "ip route add 192.168.1.0/24 via 10.0.0.1\n",
+		},
+		{
+			name:   "several blocks",
+			blocks: []string{"192.168.1.0/30", "192.168.1.9/32"},
+			via:    "10.0.0.1",
+			want:   "ip route add 192.168.1.0/30 via 10.0.0.1\nip route add 192.168.1.9/32 via 10.0.0.1\n",
+		},
+		{
+			name:   "IPv6",
+			blocks: []string{"2001:db8::/32"},
+			via:    "fe80::1",
+			want:   "ip route add 2001:db8::/32 via fe80::1\n",
+		},
+		{
+			name:    "mixed families",
+			blocks:  []string{"192.168.1.0/24"},
+			via:     "fe80::1",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := emitIPRoute(&buf, mustParseCIDRs(tt.blocks...), net.ParseIP(tt.via))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("emitIPRoute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("emitIPRoute() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEmitPython(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks []string
+		want   string
+	}{
+		{
+			name:   "single block",
+			blocks: []string{"192.168.1.0/24"},
+			want:   "import ipaddress; net = ipaddress.ip_network(\"192.168.1.0/24\")\n",
+		},
+		{
+			name:   "several blocks",
+			blocks: []string{"192.168.1.0/30", "192.168.1.9/32"},
+			want:   "import ipaddress; nets = [ipaddress.ip_network(\"192.168.1.0/30\"), ipaddress.ip_network(\"192.168.1.9/32\")]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := emitPython(&buf, mustParseCIDRs(tt.blocks...)); err != nil {
+				t.Fatalf("emitPython() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("emitPython() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEmitAnsible(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks []string
+		want   string
+	}{
+		{
+			name:   "single block",
+			blocks: []string{"192.168.1.0/24"},
+			want:   "office:\n  vars:\n    cidr_blocks:\n      - 192.168.1.0/24\n",
+		},
+		{
+			name:   "several blocks",
+			blocks: []string{"192.168.1.0/30", "192.168.1.9/32"},
+			want:   "office:\n  vars:\n    cidr_blocks:\n      - 192.168.1.0/30\n      - 192.168.1.9/32\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := emitAnsible(&buf, mustParseCIDRs(tt.blocks...), "office"); err != nil {
+				t.Fatalf("emitAnsible() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("emitAnsible() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEmitNmap(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks []string
+		want   string
+	}{
+		{
+			name:   "single block",
+			blocks: []string{"192.168.1.0/24"},
+			want:   "192.168.1.0/24\n",
+		},
+		{
+			name:   "several blocks",
+			blocks: []string{"192.168.1.0/30", "192.168.1.9/32"},
+			want:   "192.168.1.0/30 192.168.1.9/32\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := emitNmap(&buf, mustParseCIDRs(tt.blocks...)); err != nil {
+				t.Fatalf("emitNmap() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("emitNmap() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEmitBash(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks []string
+		want   string
+	}{
+		{
+			name:   "single block",
+			blocks: []string{"192.168.1.0/24"},
+			want:   "CIDRS=(192.168.1.0/24)\n",
+		},
+		{
+			name:   "several blocks",
+			blocks: []string{"192.168.1.0/24", "10.0.0.0/8", "2001:db8::/32"},
+			want:   "CIDRS=(192.168.1.0/24 10.0.0.0/8 2001:db8::/32)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := emitBash(&buf, mustParseCIDRs(tt.blocks...)); err != nil {
+				t.Fatalf("emitBash() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("emitBash() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEmitCiscoACL(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocks  []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "/24",
+			blocks: []string{"192.168.1.0/24"},
+			want:   "permit ip 192.168.1.0 0.0.0.255 any\n",
+		},
+		{
+			name:   "/30",
+			blocks: []string{"10.0.0.4/30"},
+			want:   "permit ip 10.0.0.4 0.0.0.3 any\n",
+		},
+		{
+			name:   "several blocks",
+			blocks: []string{"192.168.1.0/30", "192.168.1.9/32"},
+			want:   "permit ip 192.168.1.0 0.0.0.3 any\npermit ip 192.168.1.9 0.0.0.0 any\n",
+		},
+		{
+			name:    "IPv6 block",
+			blocks:  []string{"2001:db8::/32"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := emitCiscoACL(&buf, mustParseCIDRs(tt.blocks...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("emitCiscoACL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("emitCiscoACL() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "10.0.0.0/8", want: "10.0.0.0/8"},
+		{in: "fe80::/64", want: "fe80::/64"},
+		{in: "", want: "''"},
+		{in: "a b", want: "'a b'"},
+		{in: "it's", want: `'it'\''s'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := shellQuote(tt.in); got != tt.want {
+				t.Errorf("shellQuote(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunEmitBashMinimal(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("192.168.1.0\n192.168.1.1\n192.168.1.2\n192.168.1.3\n192.168.1.9\n")
+	code := run([]string{"-minimal", "-emit", "bash"}, stdin, &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+
+	want := "CIDRS=(192.168.1.0/30 192.168.1.9/32)\n"
+	if stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRunEmitIPRouteMinimal(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("192.168.1.0\n192.168.1.1\n192.168.1.9\n")
+	code := run([]string{"-minimal", "-emit", "iproute", "-via", "10.0.0.1"}, stdin, &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+
+	want := "ip route add 192.168.1.0/31 via 10.0.0.1\nip route add 192.168.1.9/32 via 10.0.0.1\n"
+	if stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRunEmitNftablesMinimal(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("192.168.1.0\n192.168.1.1\n192.168.1.2\n192.168.1.3\n192.168.1.9\n")
+	code := run([]string{"-minimal", "-emit", "nftables"}, stdin, &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+
+	want := `set cidrcalc {
+	type ipv4_addr
+	flags interval
+	elements = { 192.168.1.0/30, 192.168.1.9/32 }
+}
+`
+	if stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRunEmitUnknownFormat(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-emit", "nope"}, strings.NewReader("10.0.0.1\n"), &stdout, &stderr)
+	if code != ExitBadArgs {
+		t.Errorf("run() = %d, want %d", code, ExitBadArgs)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestShellSplit(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{input: "", want: nil},
+		{input: "  -count   -debug ", want: []string{"-count", "-debug"}},
+		{input: "-set-name 'my set'", want: []string{"-set-name", "my set"}},
+		{input: `-set-name "my \"set\""`, want: []string{"-set-name", `my "set"`}},
+		{input: `-set-name my\ set`, want: []string{"-set-name", "my set"}},
+		{input: `-emit=nft'ables'`, want: []string{"-emit=nftables"}},
+		{input: `''`, want: []string{""}},
+		{input: "-set-name 'my set", wantErr: true},
+		{input: `-set-name "my set`, wantErr: true},
+		{input: `-count \`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := shellSplit(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("shellSplit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("shellSplit() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunOptsEnv(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "default applied",
+			env:        "-count",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/30 (usable hosts: 2)\n",
+		},
+		{
+			name:       "default overridden",
+			env:        "-count",
+			args:       []string{"-count=false"},
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/30\n",
+		},
+		{
+			name:       "invalid contents",
+			env:        "-set-name 'oops",
+			wantCode:   ExitBadArgs,
+			wantStderr: "Error reading CIDRCALC_OPTS: unterminated single quote\n",
+		},
+		{
+			name:       "positional argument",
+			env:        "-count 10.0.0.0/8",
+			args:       []string{"-json"},
+			wantCode:   ExitBadArgs,
+			wantStderr: "CIDRCALC_OPTS must only hold flags, found \"10.0.0.0/8\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runWithDefaults(tt.env, tt.args, strings.NewReader("192.168.1.1\n192.168.1.2\n"), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("runWithDefaults() = %d, want %d (stderr: %q)", code, tt.wantCode, stderr.String())
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("runWithDefaults() stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("runWithDefaults() stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// firstDifferingBit returns the index, counting from 0 at the most significant
+// bit, of the first bit differing between two IPv4 addresses. It is also the
+// length of their common prefix. It returns false for equal addresses.
+func firstDifferingBit(minUint, maxUint uint32) (int, bool) {
+	bit := commonPrefixLength([]uint32{minUint, maxUint})
+	return bit, bit < 32
+}
+
+// explainBits shows two IPv4 addresses in binary, one octet per group, with a
+// caret under the first bit they differ at, which caps the prefix length.
+func explainBits(minUint, maxUint uint32) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "min %s (%s)\n", binaryIP(minUint), uint32ToIP(minUint))
+	fmt.Fprintf(&b, "max %s (%s)\n", binaryIP(maxUint), uint32ToIP(maxUint))
+
+	bit, ok := firstDifferingBit(minUint, maxUint)
+	if !ok {
+		b.WriteString("no bit differs, so the prefix is /32\n")
+		return b.String()
+	}
+	// Skip the "min " label and the dots separating the octets before bit.
+	fmt.Fprintf(&b, "%s^ bit %d differs, so the prefix is /%d\n", strings.Repeat(" ", 4+bit+bit/8), bit, bit)
+	return b.String()
+}
+
+// binaryIP formats an IPv4 address in binary with dots between the octets.
+func binaryIP(u uint32) string {
+	return fmt.Sprintf("%08b.%08b.%08b.%08b", byte(u>>24), byte(u>>16), byte(u>>8), byte(u))
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestFirstDifferingBit(t *testing.T) {
+	tests := []struct {
+		min, max string
+		want     int
+		wantOK   bool
+	}{
+		{min: "192.168.1.1", max: "192.168.1.2", want: 30, wantOK: true},
+		{min: "192.168.1.0", max: "192.168.1.255", want: 24, wantOK: true},
+		{min: "10.0.0.1", max: "192.168.1.1", want: 0, wantOK: true},
+		{min: "10.0.0.1", max: "10.0.0.1", want: 32, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.min+"-"+tt.max, func(t *testing.T) {
+			got, ok := firstDifferingBit(ipToUint32(net.ParseIP(tt.min)), ipToUint32(net.ParseIP(tt.max)))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("firstDifferingBit() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestExplainBits(t *testing.T) {
+	got := explainBits(ipToUint32(net.ParseIP("192.168.1.1")), ipToUint32(net.ParseIP("192.168.1.2")))
+	want := "min 11000000.10101000.00000001.00000001 (192.168.1.1)\n" +
+		"max 11000000.10101000.00000001.00000010 (192.168.1.2)\n" +
+		"                                     ^ bit 30 differs, so the prefix is /30\n"
+	if got != want {
+		t.Errorf("explainBits() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// addressCandidate matches the runs of characters that can make up an IPv4
+// or IPv6 address, optionally followed by a port. Matching maximal runs
+// rather than address patterns keeps longer dotted strings such as the
+// version number 1.2.3.4.5 from yielding an address.
+var addressCandidate = regexp.MustCompile(`[0-9A-Fa-f:.]+`)
+
+// extractIPs returns the IP addresses mentioned in a line of free-form text,
+// in order. Words around the addresses, trailing punctuation and ports such
+// as in 10.0.0.1:443 are ignored.
+//
+// A candidate must stand on its own, not be glued to a word: otherwise the
+// ::Add of Class::Add would be read as the IPv6 address ::add. For the same
+// reason, IPv6 addresses need at least two groups of hex digits.
+func extractIPs(line string) []net.IP {
+	var ips []net.IP
+	for _, loc := range addressCandidate.FindAllStringIndex(line, -1) {
+		start, end := loc[0], loc[1]
+		if start > 0 && isWordByte(line[start-1]) || end < len(line) && isWordByte(line[end]) {
+			continue
+		}
+		candidate := strings.TrimRight(line[start:end], ".:")
+		ip := net.ParseIP(candidate)
+		if ip == nil {
+			host, _, err := net.SplitHostPort(candidate)
+			if err != nil {
+				continue
+			}
+			if ip = net.ParseIP(host); ip == nil {
+				continue
+			}
+			candidate = host
+		}
+		if ip.To4() == nil && hexGroups(candidate) < 2 {
+			continue
+		}
+		ips = append(ips, normalizeIP(ip))
+	}
+	return ips
+}
+
+// isWordByte reports whether c can be part of an identifier.
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// hexGroups returns the number of non-empty colon-separated groups of addr.
+func hexGroups(addr string) int {
+	n := 0
+	for _, group := range strings.Split(addr, ":") {
+		if group != "" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtractIPs(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{
+			name: "address among words",
+			line: "Accepted publickey for root from 192.168.1.10 port 52144 ssh2",
+			want: []string{"192.168.1.10"},
+		},
+		{
+			name: "address with a port and trailing punctuation",
+			line: "connect to 10.0.0.1:443 failed, retrying 10.0.0.2.",
+			want: []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name: "IPv6 address in brackets",
+			line: "GET / from [2001:db8::1]:8080 at 12:34:56",
+			want: []string{"2001:db8::1"},
+		},
+		{
+			name: "longer dotted strings are not addresses",
+			line: "upgraded to 1.2.3.4.5, build 300.1.1.1",
+		},
+		{
+			name: "no address",
+			line: "nothing to see here",
+		},
+		{
+			name: "C++ scope resolution",
+			line: "call Class::Add then Foo::Bar and ns::cafe",
+		},
+		{
+			name: "hex words glued to letters",
+			line: "see dead:beefy and xdead:beef",
+		},
+		{
+			name: "single IPv6 group",
+			line: "loopback ::1 or prefix fe80::",
+		},
+		{
+			name: "IPv4-mapped IPv6 address",
+			line: "peer ::ffff:192.0.2.1 connected",
+			want: []string{"192.0.2.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractIPs(tt.line)
+			var want []net.IP
+			for _, s := range tt.want {
+				want = append(want, normalizeIP(net.ParseIP(s)))
+			}
+			if !slices.EqualFunc(got, want, net.IP.Equal) {
+				t.Errorf("extractIPs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunExtract(t *testing.T) {
+	log := `Oct 15 10:00:01 gw sshd[812]: Failed password for admin from 192.168.1.10 port 52144 ssh2
+Oct 15 10:00:02 gw sshd[812]: Connection closed by 192.168.1.77 port 52144 [preauth]
+Oct 15 10:00:05 gw kernel: eth0: link up
+Oct 15 10:00:09 gw sshd[815]: Accepted publickey for deploy from 192.168.1.130 port 40022 ssh2
+`
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-extract"}, strings.NewReader(log), &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+	if want := "192.168.1.0/24\n"; stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+}
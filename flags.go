@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// hiddenFlags lists the flags meant for troubleshooting, left out of the
+// usage message.
+var hiddenFlags = map[string]bool{
+	"dump-uint": true,
+}
+
+// printUsage prints the usage message of flags to w, leaving out the hidden
+// flags.
+func printUsage(flags *flag.FlagSet, w io.Writer) {
+	visible := flag.NewFlagSet(flags.Name(), flag.ContinueOnError)
+	visible.SetOutput(w)
+	flags.VisitAll(func(f *flag.Flag) {
+		if !hiddenFlags[f.Name] {
+			visible.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	fmt.Fprintf(w, "Usage of %s:\n", flags.Name())
+	visible.PrintDefaults()
+}
+
+// cidrFlag is a flag.Value holding an optional CIDR.
+type cidrFlag struct {
+	*net.IPNet
+}
+
+func (f *cidrFlag) String() string {
+	if f.IPNet == nil {
+		return ""
+	}
+	return f.IPNet.String()
+}
+
+func (f *cidrFlag) Set(s string) error {
+	_, ipnet, err := parseCIDR(s)
+	if err != nil {
+		return err
+	}
+	f.IPNet = ipnet
+	return nil
+}
+
+// cidrListFlag is a flag.Value collecting the CIDRs given each time the flag
+// is repeated.
+type cidrListFlag []*net.IPNet
+
+func (f *cidrListFlag) String() string {
+	return fmt.Sprint([]*net.IPNet(*f))
+}
+
+func (f *cidrListFlag) Set(s string) error {
+	_, ipnet, err := parseCIDR(s)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, ipnet)
+	return nil
+}
+
+// stringListFlag is a flag.Value collecting the values given each time the
+// flag is repeated.
+type stringListFlag []string
+
+func (f *stringListFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringListFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
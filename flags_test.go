@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestCIDRFlag(t *testing.T) {
+	var f cidrFlag
+	if f.String() != "" {
+		t.Errorf("String() of an unset flag = %q, want empty", f.String())
+	}
+	if err := f.Set("192.168.1.5/24"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if f.String() != "192.168.1.0/24" {
+		t.Errorf("String() = %q, want 192.168.1.0/24", f.String())
+	}
+	if err := f.Set("192.168.1.0/33"); err == nil {
+		t.Error("Set() error = nil, want an error for an invalid CIDR")
+	}
+}
+
+func TestCIDRListFlag(t *testing.T) {
+	var f cidrListFlag
+	for _, s := range []string{"10.0.0.0/31", "10.0.0.2/31"} {
+		if err := f.Set(s); err != nil {
+			t.Fatalf("Set(%q) error = %v", s, err)
+		}
+	}
+	if f.String() != "[10.0.0.0/31 10.0.0.2/31]" {
+		t.Errorf("String() = %q, want [10.0.0.0/31 10.0.0.2/31]", f.String())
+	}
+	if err := f.Set("nope"); err == nil {
+		t.Error("Set() error = nil, want an error for an invalid CIDR")
+	}
+}
+
+func TestStringListFlag(t *testing.T) {
+	var f stringListFlag
+	for _, s := range []string{"a.txt", "b.txt"} {
+		if err := f.Set(s); err != nil {
+			t.Fatalf("Set(%q) error = %v", s, err)
+		}
+	}
+	if f.String() != "a.txt,b.txt" {
+		t.Errorf("String() = %q, want a.txt,b.txt", f.String())
+	}
+}
+
+func TestPrintUsageHidesFlags(t *testing.T) {
+	flags := flag.NewFlagSet("cidrcalc", flag.ContinueOnError)
+	flags.Bool("debug", false, "Enable debug output")
+	flags.Bool("dump-uint", false, "Print uint32 values")
+
+	var buf bytes.Buffer
+	printUsage(flags, &buf)
+	if !strings.Contains(buf.String(), "-debug") {
+		t.Errorf("usage = %q, want it to list -debug", buf.String())
+	}
+	if strings.Contains(buf.String(), "dump-uint") {
+		t.Errorf("usage = %q, want -dump-uint hidden", buf.String())
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"iter"
+	"net"
+)
+
+// Hosts returns an iterator over every address of n, from the network address
+// to the last one, without materializing them all. Each yielded IP is a fresh
+// copy that callers may keep.
+//
+// A /8 holds 16 million addresses and an IPv6 /64 more than anyone can walk
+// through, so callers should check the prefix length before iterating a block
+// they don't control.
+func Hosts(n *net.IPNet) iter.Seq[net.IP] {
+	return func(yield func(net.IP) bool) {
+		ip := n.IP.Mask(n.Mask)
+		if ip == nil {
+			return
+		}
+		last := broadcastIP(&net.IPNet{IP: ip, Mask: n.Mask})
+		for {
+			if !yield(append(net.IP(nil), ip...)) {
+				return
+			}
+			if ip.Equal(last) {
+				return
+			}
+			incrementIP(ip)
+		}
+	}
+}
+
+// incrementIP adds one to ip in place, wrapping around at the end of the
+// address space.
+func incrementIP(ip net.IP) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			return
+		}
+	}
+}
+
+// usableInputs counts the ips that are usable hosts of the IPv4 block, that
+// is neither its network nor its broadcast address, and returns the inputs
+// that are not. Every address of a /31 or /32 is usable (see usableHosts).
+func usableInputs(block *net.IPNet, ips []net.IP) (int, []net.IP) {
+	ones, bits := block.Mask.Size()
+	if bits != 32 || ones >= 31 {
+		return len(ips), nil
+	}
+	network, broadcast := block.IP, broadcastIP(block)
+	usable := 0
+	var edges []net.IP
+	for _, ip := range ips {
+		if ip.Equal(network) || ip.Equal(broadcast) {
+			edges = append(edges, ip)
+			continue
+		}
+		usable++
+	}
+	return usable, edges
+}
+
+// prefixForHosts returns the length of the smallest IPv4 prefix whose block
+// holds at least n usable hosts, as counted by usableHosts, or -1 when even a
+// /0 is too small.
+func prefixForHosts(n int) int {
+	for prefixLen := 32; prefixLen >= 0; prefixLen-- {
+		if usableHosts(prefixLen) >= uint64(n) {
+			return prefixLen
+		}
+	}
+	return -1
+}
+
+// exampleHostsNetwork is the network the block printed by -hosts starts at,
+// unless -hosts-network gives another one.
+var exampleHostsNetwork = net.IPv4(10, 0, 0, 0).To4()
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHosts(t *testing.T) {
+	tests := []struct {
+		cidr string
+		want []string
+	}{
+		{
+			cidr: "192.168.1.4/30",
+			want: []string{"192.168.1.4", "192.168.1.5", "192.168.1.6", "192.168.1.7"},
+		},
+		{
+			cidr: "192.168.1.7/32",
+			want: []string{"192.168.1.7"},
+		},
+		{
+			cidr: "192.168.1.255/31",
+			want: []string{"192.168.1.254", "192.168.1.255"},
+		},
+		{
+			cidr: "255.255.255.254/31",
+			want: []string{"255.255.255.254", "255.255.255.255"},
+		},
+		{
+			cidr: "2001:db8::fe/127",
+			want: []string{"2001:db8::fe", "2001:db8::ff"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			_, block, err := net.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []net.IP
+			for ip := range Hosts(block) {
+				got = append(got, ip)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Hosts() = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i].String() != tt.want[i] {
+					t.Errorf("Hosts()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHostsStopsEarly(t *testing.T) {
+	_, block, _ := net.ParseCIDR("10.0.0.0/8")
+	count := 0
+	for range Hosts(block) {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	if count != 3 {
+		t.Errorf("iterated %d addresses, want 3", count)
+	}
+}
+
+func TestUsableInputs(t *testing.T) {
+	tests := []struct {
+		name       string
+		cidr       string
+		ips        []string
+		wantUsable int
+		wantEdges  []string
+	}{
+		{
+			name:       "network and broadcast of a /24",
+			cidr:       "192.168.1.0/24",
+			ips:        []string{"192.168.1.0", "192.168.1.10", "192.168.1.20", "192.168.1.255"},
+			wantUsable: 2,
+			wantEdges:  []string{"192.168.1.0", "192.168.1.255"},
+		},
+		{
+			name:       "hosts only",
+			cidr:       "192.168.1.0/24",
+			ips:        []string{"192.168.1.1", "192.168.1.254"},
+			wantUsable: 2,
+		},
+		{
+			name:       "both addresses of a /31 are usable",
+			cidr:       "192.168.1.0/31",
+			ips:        []string{"192.168.1.0", "192.168.1.1"},
+			wantUsable: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, block, _ := net.ParseCIDR(tt.cidr)
+			var ips []net.IP
+			for _, s := range tt.ips {
+				ips = append(ips, net.ParseIP(s))
+			}
+			usable, edges := usableInputs(block, ips)
+			var gotEdges []string
+			for _, ip := range edges {
+				gotEdges = append(gotEdges, ip.String())
+			}
+			if usable != tt.wantUsable || !slices.Equal(gotEdges, tt.wantEdges) {
+				t.Errorf("usableInputs() = %d, %v, want %d, %v", usable, gotEdges, tt.wantUsable, tt.wantEdges)
+			}
+		})
+	}
+}
+
+func TestPrefixForHosts(t *testing.T) {
+	type testCase struct {
+		n    int
+		want int
+	}
+	tests := []testCase{
+		{n: 1, want: 32},
+		{n: 2, want: 31},
+		{n: 3, want: 29},
+		{n: 50, want: 26},
+		{n: 254, want: 24},
+		{n: 255, want: 23},
+	}
+	// Counts past a /1 only fit in a 64-bit int.
+	if strconv.IntSize == 64 {
+		whole := int(usableHosts(0))
+		tests = append(tests, testCase{n: whole, want: 0}, testCase{n: whole + 1, want: -1})
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.n), func(t *testing.T) {
+			if got := prefixForHosts(tt.n); got != tt.want {
+				t.Errorf("prefixForHosts(%d) = %d, want %d", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunHosts(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+	}{
+		{
+			name:       "default network",
+			args:       []string{"-hosts", "50"},
+			wantCode:   ExitOK,
+			wantStdout: "/26 (usable hosts: 62), e.g. 10.0.0.0/26\n",
+		},
+		{
+			name:       "network from -hosts-network",
+			args:       []string{"-hosts", "255", "-hosts-network", "192.168.4.0/22"},
+			wantCode:   ExitOK,
+			wantStdout: "/23 (usable hosts: 510), e.g. 192.168.4.0/23\n",
+		},
+		{
+			name:     "-hosts-network too small",
+			args:     []string{"-hosts", "255", "-hosts-network", "192.168.4.0/24"},
+			wantCode: ExitBadArgs,
+		},
+		{
+			name:     "negative count",
+			args:     []string{"-hosts", "-1"},
+			wantCode: ExitBadArgs,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("run() = %d, want %d (stderr: %q)", code, tt.wantCode, stderr.String())
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"math/bits"
+	"net"
+)
+
+// calculatePrefixLength128 returns the number of leading bits shared by two
+// 16-byte addresses.
+func calculatePrefixLength128(minIP, maxIP net.IP) int {
+	for i := 0; i < net.IPv6len; i++ {
+		if diff := minIP[i] ^ maxIP[i]; diff != 0 {
+			return i*8 + bits.LeadingZeros8(diff)
+		}
+	}
+	return 128
+}
+
+// ipv6Alignments lists the IPv6 allocation sizes accepted by -align.
+var ipv6Alignments = []int{48, 56, 64}
+
+// alignIPv6 widens an IPv6 block longer than /prefix to the /prefix holding
+// it, so that it matches a standard allocation size. IPv4 blocks and blocks
+// already /prefix or shorter are returned as they are.
+func alignIPv6(block *net.IPNet, prefix int) *net.IPNet {
+	ones, bits := block.Mask.Size()
+	if bits != 128 || ones <= prefix {
+		return block
+	}
+	mask := net.CIDRMask(prefix, 128)
+	return &net.IPNet{IP: block.IP.Mask(mask), Mask: mask}
+}
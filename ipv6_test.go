@@ -0,0 +1,144 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestCalculateCIDRIPv6Subnets(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+		want  string
+	}{
+		{
+			name:  "single /64",
+			input: []string{"2001:db8:0:1::/64"},
+			want:  "2001:db8:0:1::/64",
+		},
+		{
+			name:  "two adjacent /64s straddling a /63",
+			input: []string{"2001:db8:0:1::/64", "2001:db8:0:2::/64"},
+			want:  "2001:db8::/62",
+		},
+		{
+			name:  "two adjacent /64s in one /63",
+			input: []string{"2001:db8:0:4::/64", "2001:db8:0:5::/64"},
+			want:  "2001:db8:0:4::/63",
+		},
+		{
+			name:  "four adjacent /64s",
+			input: []string{"2001:db8:0:7::/64", "2001:db8:0:4::/64", "2001:db8:0:6::/64", "2001:db8:0:5::/64"},
+			want:  "2001:db8:0:4::/62",
+		},
+		{
+			name:  "/64s in different /48s",
+			input: []string{"2001:db8:1:ff::/64", "2001:db8:2::/64"},
+			want:  "2001:db8::/46",
+		},
+		{
+			name:  "host addresses",
+			input: []string{"2001:db8::1", "2001:db8::2"},
+			want:  "2001:db8::/126",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ips, err := parseIPsFromReader(strings.NewReader(strings.Join(tt.input, "\n")), parseOptions{})
+			if err != nil {
+				t.Fatalf("parseIPsFromReader() error = %v", err)
+			}
+			got, err := calculateCIDR(ips)
+			if err != nil {
+				t.Fatalf("calculateCIDR() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("calculateCIDR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateCIDRMixedFamilies(t *testing.T) {
+	_, err := calculateCIDR([]net.IP{net.ParseIP("192.168.1.1"), net.ParseIP("2001:db8::1")})
+	if err == nil {
+		t.Error("calculateCIDR() error = nil, want an error for mixed families")
+	}
+}
+
+func TestCalculatePrefixLength128(t *testing.T) {
+	tests := []struct {
+		minIP string
+		maxIP string
+		want  int
+	}{
+		{minIP: "2001:db8::1", maxIP: "2001:db8::1", want: 128},
+		{minIP: "2001:db8::", maxIP: "2001:db8::1", want: 127},
+		{minIP: "2001:db8::", maxIP: "2001:db8:0:0:ffff:ffff:ffff:ffff", want: 64},
+		{minIP: "::", maxIP: "ffff::", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.minIP+"-"+tt.maxIP, func(t *testing.T) {
+			got := calculatePrefixLength128(net.ParseIP(tt.minIP), net.ParseIP(tt.maxIP))
+			if got != tt.want {
+				t.Errorf("calculatePrefixLength128() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIPv6Spellings(t *testing.T) {
+	input := strings.Join([]string{
+		"2001:db8::1",
+		"2001:DB8::1",
+		"2001:0db8:0000:0000:0000:0000:0000:0001",
+		"2001:0DB8::0001",
+		"2001:db8:0:0::1",
+	}, "\n")
+
+	ips, err := parseIPsFromReader(strings.NewReader(input), parseOptions{})
+	if err != nil {
+		t.Fatalf("parseIPsFromReader() error = %v", err)
+	}
+	if len(ips) != 5 {
+		t.Fatalf("parseIPsFromReader() got %d IPs, want 5", len(ips))
+	}
+	for i, ip := range ips {
+		if ip.String() != "2001:db8::1" {
+			t.Errorf("IP[%d] = %v, want 2001:db8::1", i, ip)
+		}
+	}
+
+	unique := uniqueIPs(ips)
+	if len(unique) != 1 {
+		t.Errorf("uniqueIPs() = %v, want a single entry", unique)
+	}
+}
+
+func TestAlignIPv6(t *testing.T) {
+	tests := []struct {
+		name   string
+		cidr   string
+		prefix int
+		want   string
+	}{
+		{name: "two /64s to /48", cidr: "2001:db8:0:100::/55", prefix: 48, want: "2001:db8::/48"},
+		{name: "two /64s to /56", cidr: "2001:db8:0:10::/59", prefix: 56, want: "2001:db8::/56"},
+		{name: "single address to /64", cidr: "2001:db8::1/128", prefix: 64, want: "2001:db8::/64"},
+		{name: "already broader", cidr: "2001:db8::/40", prefix: 48, want: "2001:db8::/40"},
+		{name: "already aligned", cidr: "2001:db8::/48", prefix: 48, want: "2001:db8::/48"},
+		{name: "IPv4 is left alone", cidr: "192.168.1.0/24", prefix: 48, want: "192.168.1.0/24"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, block, _ := net.ParseCIDR(tt.cidr)
+			if got := alignIPv6(block, tt.prefix); got.String() != tt.want {
+				t.Errorf("alignIPv6() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
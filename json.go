@@ -0,0 +1,44 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+)
+
+// jsonSchema is the JSON Schema describing the objects printed with -json.
+// Keep it in sync with jsonResult.
+//
+//go:embed schema.json
+var jsonSchema string
+
+// jsonResult is the object printed for each block with -json.
+type jsonResult struct {
+	CIDR      string `json:"cidr"`
+	Prefix    int    `json:"prefix"`
+	Network   string `json:"network"`
+	Broadcast string `json:"broadcast"`
+	Hosts     uint64 `json:"hosts"`
+}
+
+// writeJSONResult writes the details of an IPv4 block to w as a JSON object,
+// on a single line unless indent is set.
+func writeJSONResult(w io.Writer, block *net.IPNet, indent bool) error {
+	prefixLen, bits := block.Mask.Size()
+	if bits != 32 {
+		return fmt.Errorf("-json only supports IPv4 blocks")
+	}
+	enc := json.NewEncoder(w)
+	if indent {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(jsonResult{
+		CIDR:      block.String(),
+		Prefix:    prefixLen,
+		Network:   block.IP.String(),
+		Broadcast: broadcastIP(block).String(),
+		Hosts:     usableHosts(prefixLen),
+	})
+}
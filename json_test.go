@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"testing"
+)
+
+// schema holds the parts of a JSON Schema that jsonSchema relies on.
+type schema struct {
+	Type       string `json:"type"`
+	Properties map[string]struct {
+		Type    string   `json:"type"`
+		Minimum *float64 `json:"minimum"`
+		Maximum *float64 `json:"maximum"`
+	} `json:"properties"`
+	Required             []string `json:"required"`
+	AdditionalProperties *bool    `json:"additionalProperties"`
+}
+
+// validate checks the JSON object in data against s.
+func (s schema) validate(data []byte) error {
+	var object map[string]any
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			return fmt.Errorf("missing required property %q", name)
+		}
+	}
+	for name, value := range object {
+		prop, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				return fmt.Errorf("unexpected property %q", name)
+			}
+			continue
+		}
+		switch prop.Type {
+		case "string":
+			if _, ok := value.(string); !ok {
+				return fmt.Errorf("property %q = %v, want a string", name, value)
+			}
+		case "integer":
+			n, ok := value.(float64)
+			if !ok || n != math.Trunc(n) {
+				return fmt.Errorf("property %q = %v, want an integer", name, value)
+			}
+			if prop.Minimum != nil && n < *prop.Minimum || prop.Maximum != nil && n > *prop.Maximum {
+				return fmt.Errorf("property %q = %v is out of bounds", name, value)
+			}
+		default:
+			return fmt.Errorf("property %q has unsupported type %q", name, prop.Type)
+		}
+	}
+	return nil
+}
+
+func TestWriteJSONResultMatchesSchema(t *testing.T) {
+	var s schema
+	if err := json.Unmarshal([]byte(jsonSchema), &s); err != nil {
+		t.Fatalf("embedded schema is not valid JSON: %v", err)
+	}
+	if s.Type != "object" {
+		t.Fatalf("schema type = %q, want object", s.Type)
+	}
+
+	for _, cidr := range []string{"0.0.0.0/0", "192.168.1.0/24", "10.0.0.0/31", "10.0.0.1/32"} {
+		t.Run(cidr, func(t *testing.T) {
+			_, block, _ := net.ParseCIDR(cidr)
+			var buf bytes.Buffer
+			if err := writeJSONResult(&buf, block, false); err != nil {
+				t.Fatalf("writeJSONResult() error = %v", err)
+			}
+			if err := s.validate(buf.Bytes()); err != nil {
+				t.Errorf("writeJSONResult() = %s, does not match the schema: %v", strings.TrimSpace(buf.String()), err)
+			}
+		})
+	}
+}
+
+func TestWriteJSONResult(t *testing.T) {
+	_, block, _ := net.ParseCIDR("192.168.1.0/24")
+	var buf bytes.Buffer
+	if err := writeJSONResult(&buf, block, false); err != nil {
+		t.Fatalf("writeJSONResult() error = %v", err)
+	}
+	want := `{"cidr":"192.168.1.0/24","prefix":24,"network":"192.168.1.0","broadcast":"192.168.1.255","hosts":254}` + "\n"
+	if buf.String() != want {
+		t.Errorf("writeJSONResult() = %q, want %q", buf.String(), want)
+	}
+
+	_, block, _ = net.ParseCIDR("2001:db8::/64")
+	if err := writeJSONResult(&buf, block, false); err == nil {
+		t.Errorf("writeJSONResult() of an IPv6 block succeeded, want an error")
+	}
+}
+
+func TestWriteJSONResultIndent(t *testing.T) {
+	_, block, _ := net.ParseCIDR("192.168.1.0/24")
+	tests := []struct {
+		name   string
+		indent bool
+		lines  int
+	}{
+		{name: "compact", indent: false, lines: 1},
+		{name: "indented", indent: true, lines: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeJSONResult(&buf, block, tt.indent); err != nil {
+				t.Fatalf("writeJSONResult() error = %v", err)
+			}
+			if got := strings.Count(buf.String(), "\n"); got != tt.lines {
+				t.Errorf("writeJSONResult() = %q, want %d lines, got %d", buf.String(), tt.lines, got)
+			}
+		})
+	}
+}
+
+func TestRunIndentRequiresJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-indent"}, strings.NewReader("10.0.0.1\n"), &stdout, &stderr)
+	if code != ExitBadArgs {
+		t.Errorf("run() = %d, want %d", code, ExitBadArgs)
+	}
+}
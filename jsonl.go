@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonlRecord is the object expected on each input line with -jsonl.
+type jsonlRecord struct {
+	IP string `json:"ip"`
+}
+
+// jsonlResult is the object printed for each result with -jsonl.
+type jsonlResult struct {
+	CIDR string `json:"cidr"`
+}
+
+// parseJSONLRecord extracts the address from a -jsonl input line. The
+// returned address may still be a CIDR or invalid; it is false only when the
+// line isn't a JSON object with a non-empty "ip" field.
+func parseJSONLRecord(line string) (string, bool) {
+	var record jsonlRecord
+	if err := json.Unmarshal([]byte(line), &record); err != nil || record.IP == "" {
+		return line, false
+	}
+	return record.IP, true
+}
+
+// writeJSONLResult writes cidr to w as a single-line JSON object.
+func writeJSONLResult(w io.Writer, cidr string) error {
+	return json.NewEncoder(w).Encode(jsonlResult{CIDR: cidr})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseIPsFromReaderJSONL(t *testing.T) {
+	input := `{"ip":"192.168.1.1"}
+{"ip":"192.168.1.2","host":"web-1"}
+{"ip":"not-an-ip"}
+{"host":"no-ip"}
+192.168.1.3
+{"ip":"10.0.0.0/30"}
+`
+	ips, err := parseIPsFromReader(strings.NewReader(input), parseOptions{jsonl: true})
+	if err != nil {
+		t.Fatalf("parseIPsFromReader() error = %v", err)
+	}
+
+	want := []string{"192.168.1.1", "192.168.1.2", "10.0.0.0", "10.0.0.3"}
+	if len(ips) != len(want) {
+		t.Fatalf("parseIPsFromReader() = %v, want %v", ips, want)
+	}
+	for i := range want {
+		if ips[i].String() != want[i] {
+			t.Errorf("parseIPsFromReader() IP[%d] = %v, want %v", i, ips[i], want[i])
+		}
+	}
+}
+
+func TestWriteJSONLResult(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeJSONLResult(&buf, "192.168.1.0/24"); err != nil {
+		t.Fatalf("writeJSONLResult() error = %v", err)
+	}
+	if want := "{\"cidr\":\"192.168.1.0/24\"}\n"; buf.String() != want {
+		t.Errorf("writeJSONLResult() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRunJSONLBatch(t *testing.T) {
+	input := `{"ip":"192.168.1.1"}
+{"ip":"192.168.1.200"}
+
+{"ip":"10.0.0.1"}
+{"ip":"10.0.0.2"}
+
+{"bad":true}
+`
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-jsonl", "-batch"}, strings.NewReader(input), &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+
+	want := "{\"cidr\":\"192.168.1.0/24\"}\n{\"cidr\":\"10.0.0.0/30\"}\n"
+	if stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+	if !strings.Contains(stderr.String(), "No valid IPs provided in group 3.") {
+		t.Errorf("run() stderr = %q, want a note about group 3", stderr.String())
+	}
+}
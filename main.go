@@ -2,74 +2,1025 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"math/bits"
+	"math/rand"
 	"net"
 	"os"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Exit codes returned by cidrcalc. Scripts can rely on these to tell failure
+// modes apart.
+const (
+	ExitOK          = 0  // A CIDR was printed.
+	ExitFailure     = 1  // Reading input or calculating the CIDR failed.
+	ExitNoInput     = 2  // No valid IPs were provided.
+	ExitResolveFail = 3  // The -hostname lookup failed.
+	ExitBadArgs     = 64 // The command line could not be parsed (EX_USAGE).
 )
 
 func main() {
-	hostname := flag.String("hostname", "", "Hostname to resolve and calculate the CIDR for its IPs")
-	debug := flag.Bool("debug", false, "Enable debug output")
-	flag.Parse()
+	os.Exit(runWithDefaults(os.Getenv(optsEnv), os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
 
-	var ips []net.IP
-	var err error
+// run executes cidrcalc with the given command-line arguments (without the
+// program name) and returns the process exit code. All I/O goes through the
+// given streams so that run can be driven end to end from tests.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	return runWithDefaults("", args, stdin, stdout, stderr)
+}
+
+// runWithDefaults is like run, with the default flags held by defaults, the
+// value of optsEnv, parsed before args.
+func runWithDefaults(defaults string, args []string, stdin io.Reader, stdout, stderr io.Writer) (code int) {
+	flags := flag.NewFlagSet("cidrcalc", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { printUsage(flags, stderr) }
+	hostname := flags.String("hostname", "", "Hostname to resolve and calculate the CIDR for its IPs")
+	var includes stringListFlag
+	flags.Var(&includes, "include", "Read extra IPs, one per line, from `file` and add them to every group of input IPs (repeatable)")
+	resolveStdin := flags.Bool("resolve-stdin", false, "Read hostnames from stdin, one per line, and calculate the CIDR for all their IPs")
+	watch := flags.Duration("watch", 0, "With -hostname, re-resolve every `interval` and print the CIDR whenever it changes")
+	ipRange := flags.String("range", "", "Calculate the CIDR for the inclusive IPv4 `range` first-last")
+	countBlocks := flags.Bool("count-blocks", false, "With -range, print the number of CIDR blocks needed to cover the range exactly")
+	combine := flags.Bool("combine", false, "Print the smallest block containing both CIDRs given as arguments")
+	hosts := flags.Int("hosts", 0, "Print the smallest IPv4 prefix holding `N` usable hosts, with an example block starting at the -hosts-network network")
+	mergeAdjacentRanges := flags.Bool("merge-adjacent-ranges", false, "Read first-last IPv4 ranges, one per line, and print them with overlapping and touching ranges merged")
+	url := flags.String("url", "", "Aggregate the newline-delimited IPs served at the HTTP(S) `URL`")
+	timeout := flags.Duration("timeout", 30*time.Second, "With -url, give up on the request after `duration` (0 means never)")
+	pcapPath := flags.String("pcap", "", "Aggregate the source and destination IPv4 addresses of the packets in the pcap `file`")
+	allowEmpty := flags.Bool("allow-empty", false, "Exit with status 0 and print nothing when no valid input is provided, instead of failing")
+	debug := flags.Bool("debug", false, "Enable debug output")
+	cidrHost := flags.Bool("cidr-host", false, "For input lines in CIDR notation, aggregate the host address (e.g. 192.168.1.57 in 192.168.1.57/24) instead of the whole block")
+	allowZone := flags.Bool("allow-zone", false, "Accept IPv6 addresses with a zone such as fe80::1%eth0, ignoring the zone")
+	jsonl := flags.Bool("jsonl", false, "Read one JSON object such as {\"ip\":\"192.168.1.1\"} per input line and print each result as a JSON object")
+	jsonOutput := flags.Bool("json", false, "Print each block as a JSON object with its prefix, network, broadcast and host count")
+	formats := flags.String("formats", "", "Write the result in each of the comma-separated `formats` ("+strings.Join(outputFormats, ", ")+") to the files given by -out")
+	outTemplate := flags.String("out", "", "With -formats, the `path` of the files to write, where "+formatPlaceholder+" is replaced by the format name")
+	indent := flags.Bool("indent", false, "With -json, pretty-print each object over several lines")
+	printJSONSchema := flags.Bool("json-schema", false, "Print the JSON Schema of the objects printed by -json")
+	numeric := flags.Bool("numeric", false, "Read IPv4 addresses written as decimal integers, e.g. 3232235777 for 192.168.1.1")
+	extract := flags.Bool("extract", false, "Read free-form text such as logs and aggregate every IPv4 and IPv6 address mentioned in it (beware of version numbers that look like addresses)")
+	logFormat := flags.String("log-format", "", "Read web server access logs in the given `format` ("+strings.Join(logFormats, ", ")+") and aggregate their client addresses")
+	maskInput := flags.Bool("mask-input", false, "Read \"network mask\" pairs such as \"192.168.1.0 255.255.255.0\" and aggregate the blocks they denote")
+	nmapInput := flags.Bool("nmap-input", false, "Read nmap target specifications, which may use octet ranges such as 192.168.1.1-50")
+	auto := flags.Bool("auto", false, "Detect from the first non-empty line whether the input holds IPs, CIDRs, JSON objects or integers")
+	csvInput := flags.Bool("csv-input", false, "Read a CSV document and aggregate the IPs of the column given by -csv-column")
+	csvColumn := flags.Int("csv-column", 0, "With -csv-input, the 0-indexed `column` holding the IPs")
+	csvHeader := flags.Bool("csv-header", false, "With -csv-input, skip the first row")
+	batch := flags.Bool("batch", false, "Treat blank lines as separators between groups of IPs and print one result per group")
+	grandTotal := flags.Bool("grand-total", false, "With -batch, finish with the block enclosing the IPv4 addresses of all groups")
+	prefixOnly := flags.Bool("prefix-only", false, "Print only the prefix length of each block, e.g. 24 for 192.168.1.0/24")
+	canonical := flags.Bool("canonical", false, "Guarantee that blocks are printed with their host bits zeroed. The blocks computed, even with -floor-prefix or -split, already are, so this is a safeguard for strict downstream parsers")
+	count := flags.Bool("count", false, "Also print the number of usable host addresses in the block")
+	verify := flags.Bool("verify", false, "With -minimal, check that the printed blocks cover exactly the input addresses and fail otherwise")
+	head := flags.Int("head", 0, "Print only the first `N` blocks of each result, noting on stderr how many were left out")
+	minimal := flags.Bool("minimal", false, "Print the smallest set of IPv4 blocks covering exactly the input addresses instead of a single enclosing block")
+	emit := flags.String("emit", "", "Print the result in the given `format`: "+strings.Join(emitFormats, ", "))
+	setName := flags.String("set-name", "cidrcalc", "Name of the set produced by -emit nftables")
+	group := flags.String("group", "cidrcalc", "Name of the inventory group produced by -emit ansible")
+	via := flags.String("via", "", "Gateway `IP` of the routes printed by -emit iproute")
+	showContext := flags.Bool("context", false, "Also print the parent block (one bit shorter) and the two child blocks (one bit longer) of each result")
+	split := flags.Int("split", 0, "Split each resulting block into its /`N` subnets")
+	labelsPath := flags.String("labels", "", "With -split, read names from `file`, one per line, and print the ith subnet as \"name: CIDR\" with the ith name")
+	allowUnlabeled := flags.Bool("allow-unlabeled", false, "With -labels, print the subnets past the last name without one instead of failing")
+	minPrefix := flags.Int("min-prefix", 0, "With -split, split further any block shorter than /`N`")
+	maxPrefix := flags.Int("max-prefix", 32, "With -split, fail if a block is longer than /`N`")
+	sample := flags.Int("sample", 0, "Aggregate only `K` randomly chosen input IPs, for a quick estimate of the enclosing block")
+	seed := flags.Int64("seed", 0, "Seed the random choices of -sample and -self-check with `N` to make them reproducible (0 picks a random seed)")
+	trimPercentile := flags.Float64("trim-percentile", 0, "Drop the lowest and highest `P` percent of the IPs before aggregating, to ignore outliers")
+	align := flags.Int("align", 0, "Widen IPv6 results to the standard allocation size /`N`: 48, 56 or 64")
+	var anchors cidrListFlag
+	flags.Var(&anchors, "anchor", "With -minimal, keep the IPv4 `CIDR` as a boundary that no block spans, even when it is full (repeatable)")
+	floorPrefix := flags.Int("floor-prefix", 0, "Never print a block shorter than /`N`: when the enclosing block is wider, print one block per /N holding input IPs instead")
+	maxGap := flags.Uint("max-gap", 0, "Fail when two consecutive input IPv4 addresses are more than `N` addresses apart, e.g. to catch outliers (0 disables the check)")
+	requireContiguous := flags.Bool("require-contiguous", false, "Fail unless the input IPs fill the enclosing block without gaps")
+	allowMissingEdges := flags.Bool("allow-missing-edges", false, "With -require-contiguous, allow the network and broadcast addresses to be missing")
+	vpcCheck := flags.Bool("vpc-check", false, "Fail unless each resulting block fits the AWS VPC constraints (IPv4, /16 to /28)")
+	dropBogons := flags.Bool("drop-bogons", false, "Remove reserved and private IPv4 ranges from the result, leaving only routable blocks")
+	explainBitsFlag := flags.Bool("explain-bits", false, "Show on stderr the lowest and highest IPv4 addresses in binary, marking the first bit they differ at")
+	strictAlign := flags.Bool("strict-align", false, "Explain on stderr when CIDR alignment forces a block wider than the span of the IPs (e.g. .1 and .2 straddle a /31 boundary and need a /30)")
+	stdinTimeout := flags.Duration("stdin-timeout", 0, "Fail if stdin goes longer than `duration` without providing a line")
+	progress := flags.Bool("progress", false, "Print to stderr how many lines were read every 100000 lines, at most once per second")
+	limit := flags.Int("limit", 0, "Keep at most `N` valid input IPs in memory, counting all -batch groups and -include files, and drop the rest with a warning")
+	var hostsNetwork cidrFlag
+	flags.Var(&hostsNetwork, "hosts-network", "Start the example block printed by -hosts at the network of the IPv4 `CIDR` (10.0.0.0/8 by default)")
+	var base cidrFlag
+	flags.Var(&base, "base", "Treat input IPs outside of the `CIDR` as invalid")
+	rejectUnspecified := flags.Bool("reject-unspecified", false, "Treat the unspecified addresses 0.0.0.0 and :: as invalid, as they usually come from a parsing or data error")
+	var offsetFrom cidrFlag
+	flags.Var(&offsetFrom, "offset-from", "Print the index of each block among the subnets of its size within the `CIDR`")
+	asnMapPath := flags.String("asn-map", "", "Read \"CIDR ASN\" pairs from `file` to attribute IPs to autonomous systems, for use with -asn")
+	asn := flags.String("asn", "", "With -asn-map, only keep the IPs whose most specific prefix is announced by `ASN`")
+	var compareTo cidrFlag
+	flags.Var(&compareTo, "compare-to", "Tell on stderr whether the enclosing block is equal to, narrower or broader than, or disjoint from the reference `CIDR`")
+	compareStrategiesFlag := flags.Bool("compare-strategies", false, "Tell on stderr how many addresses the enclosing block covers next to the blocks of -minimal, and the reduction -minimal brings")
+	classify := flags.Bool("classify", false, "Tell on stderr which special range, such as private, loopback, link-local, unique local or documentation, the enclosing block falls into")
+	alertPrefix := flags.Int("alert-prefix", 0, "Warn as soon as the block enclosing the IPs read so far becomes shorter than /`N`")
+	mrtInput := flags.Bool("mrt-input", false, "Read the prefixes of a BGP table dump, one per line with an optional AS path after them, and print them merged into the fewest blocks")
+	normalize := flags.Bool("normalize", false, "Read CIDRs and print them with their host bits zeroed, reporting how many had host bits set")
+	summarizePath := flags.String("summarize-file", "", "Merge the CIDRs listed in `file` into the fewest blocks and print them")
+	inPlace := flags.Bool("i", false, "With -summarize-file, rewrite the file in place and keep the original as file.bak")
+	reportDuplicates := flags.Bool("report-duplicates", false, "With -summarize-file, list on stderr the CIDRs listed more than once")
+	keepComments := flags.Bool("keep-comments", false, "With -summarize-file, keep comments next to the blocks they describe")
+	noDedup := flags.Bool("no-dedup", false, "Keep duplicate IPs, so that debug output and -dump-uint report every occurrence")
+	selfCheck := flags.Bool("self-check", false, "Aggregate a shuffled copy of the input too and fail if the result differs")
+	visualize := flags.Bool("visualize", false, "Draw a bar on stderr showing which parts of each block hold input IPs (colored unless NO_COLOR is set)")
+	per24 := flags.Bool("per-24", false, "Print to stderr each /24 holding input IPs with their number, the most populated first")
+	spread := flags.Bool("spread", false, "Print to stderr how scattered the IPv4 addresses are within the enclosing block")
+	countUsableInputs := flags.Bool("count-usable-in-inputs", false, "Print to stderr how many input IPs are usable hosts, i.e. neither the network nor the broadcast address of the block")
+	utilizationThreshold := flags.Float64("utilization-threshold", 0, "Warn when the input IPs fill less than `P` percent of the enclosing block, suggesting -minimal instead")
+	noNewline := flags.Bool("no-newline", false, "Leave out the newline at the end of the output, e.g. to capture the CIDR into a shell variable")
+	summary := flags.Bool("summary", false, "Print a line to stderr telling how many IPs were aggregated and how much of the block they fill")
+	dumpUint := flags.Bool("dump-uint", false, "With -debug, print the uint32 value of each IPv4 address")
+	cpuProfile := flags.String("cpuprofile", "", "Write a CPU profile to `file`")
+	memProfile := flags.String("memprofile", "", "Write a heap profile to `file` before exiting")
+	quietErrors := flags.Bool("quiet-errors", false, "Leave error messages and the -progress output out of stderr and report failures through the exit status only")
+	useSyslog := flags.Bool("syslog", false, "Also send the results and error messages to the local syslog")
+	syslogTag := flags.String("syslog-tag", "cidrcalc", "Tag the syslog messages with `tag`")
+
+	// sysLog is set once -syslog has connected to the syslog daemon.
+	var sysLog syslogWriter
+
+	// errorf prints an error message to stderr, unless -quiet-errors is set,
+	// and sends it to syslog with -syslog.
+	errorf := func(format string, args ...any) {
+		if !*quietErrors {
+			fmt.Fprintf(stderr, format, args...)
+		}
+		if sysLog != nil {
+			sysLog.Err(strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+		}
+	}
+
+	defaultArgs, err := shellSplit(defaults)
+	if err != nil {
+		errorf("Error reading %s: %v\n", optsEnv, err)
+		return ExitBadArgs
+	}
+	// The defaults are parsed on their own: a word that isn't a flag would
+	// otherwise stop the parsing, silently ignoring the command-line flags.
+	if err := flags.Parse(defaultArgs); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitBadArgs
+	}
+	if flags.NArg() > 0 {
+		errorf("%s must only hold flags, found %q\n", optsEnv, flags.Arg(0))
+		return ExitBadArgs
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitBadArgs
+	}
+
+	stopProfiling, err := startProfiling(*cpuProfile, *memProfile)
+	if err != nil {
+		errorf("Error starting profiling: %v\n", err)
+		return ExitFailure
+	}
+	defer func() {
+		if err := stopProfiling(); err != nil {
+			errorf("Error writing profile: %v\n", err)
+			if code == ExitOK {
+				code = ExitFailure
+			}
+		}
+	}()
+
+	if *emit != "" {
+		if !slices.Contains(emitFormats, *emit) {
+			errorf("unknown -emit format %q, must be one of: %s\n", *emit, strings.Join(emitFormats, ", "))
+			return ExitBadArgs
+		}
+		if *jsonl || *count {
+			errorf("-emit cannot be combined with -jsonl or -count\n")
+			return ExitBadArgs
+		}
+	}
+	if *jsonOutput && (*jsonl || *emit != "" || *count || offsetFrom.IPNet != nil) {
+		errorf("-json cannot be combined with -jsonl, -emit, -count or -offset-from\n")
+		return ExitBadArgs
+	}
+	if *indent && !*jsonOutput && !strings.Contains(*formats, "json") {
+		errorf("-indent requires -json or a -formats list with json\n")
+		return ExitBadArgs
+	}
+	if (*emit == "iproute") != (*via != "") {
+		errorf("-emit iproute and -via must be used together\n")
+		return ExitBadArgs
+	}
+	var gateway net.IP
+	if *via != "" {
+		if gateway = net.ParseIP(*via); gateway == nil {
+			errorf("-via: invalid IP %q\n", *via)
+			return ExitBadArgs
+		}
+	}
+	if *prefixOnly && (*jsonOutput || *jsonl || *emit != "" || offsetFrom.IPNet != nil || *showContext) {
+		errorf("-prefix-only cannot be combined with -json, -jsonl, -emit, -offset-from or -context\n")
+		return ExitBadArgs
+	}
+	if *showContext && (*emit != "" || *jsonl || *jsonOutput) {
+		errorf("-context cannot be combined with -emit, -jsonl or -json\n")
+		return ExitBadArgs
+	}
+	if offsetFrom.IPNet != nil && (*emit != "" || *jsonl || *count) {
+		errorf("-offset-from cannot be combined with -emit, -jsonl or -count\n")
+		return ExitBadArgs
+	}
+	if *csvInput && (*jsonl || *batch) {
+		errorf("-csv-input cannot be combined with -jsonl or -batch\n")
+		return ExitBadArgs
+	}
+	if *numeric && *jsonl {
+		errorf("-numeric cannot be combined with -jsonl\n")
+		return ExitBadArgs
+	}
+	if *extract && (*jsonl || *numeric || *nmapInput || *auto || *csvInput || *resolveStdin) {
+		errorf("-extract cannot be combined with -jsonl, -numeric, -nmap-input, -auto, -csv-input or -resolve-stdin\n")
+		return ExitBadArgs
+	}
+	if *logFormat != "" {
+		if !slices.Contains(logFormats, *logFormat) {
+			errorf("unknown -log-format %q, must be one of: %s\n", *logFormat, strings.Join(logFormats, ", "))
+			return ExitBadArgs
+		}
+		if *jsonl || *numeric || *nmapInput || *auto || *extract || *maskInput || *csvInput || *resolveStdin {
+			errorf("-log-format cannot be combined with -jsonl, -numeric, -nmap-input, -auto, -extract, -mask-input, -csv-input or -resolve-stdin\n")
+			return ExitBadArgs
+		}
+	}
+	if *maskInput && (*numeric || *nmapInput || *auto || *extract) {
+		errorf("-mask-input cannot be combined with -numeric, -nmap-input, -auto or -extract\n")
+		return ExitBadArgs
+	}
+	if *nmapInput && (*numeric || *auto) {
+		errorf("-nmap-input cannot be combined with -numeric or -auto\n")
+		return ExitBadArgs
+	}
+	if *auto && (*csvInput || *resolveStdin) {
+		errorf("-auto cannot be combined with -csv-input or -resolve-stdin\n")
+		return ExitBadArgs
+	}
+	if *csvColumn < 0 {
+		errorf("-csv-column must not be negative\n")
+		return ExitBadArgs
+	}
+	if *labelsPath != "" && (*split == 0 || *jsonOutput || *jsonl || *emit != "" || *formats != "" || *showContext) {
+		errorf("-labels requires -split and cannot be combined with -json, -jsonl, -emit, -formats or -context\n")
+		return ExitBadArgs
+	}
+	if *allowUnlabeled && *labelsPath == "" {
+		errorf("-allow-unlabeled requires -labels\n")
+		return ExitBadArgs
+	}
+	if *verify && (!*minimal || *dropBogons || *floorPrefix > 0) {
+		errorf("-verify requires -minimal and cannot be combined with -drop-bogons or -floor-prefix\n")
+		return ExitBadArgs
+	}
+	if len(anchors) > 0 {
+		if !*minimal {
+			errorf("-anchor requires -minimal\n")
+			return ExitBadArgs
+		}
+		for _, anchor := range anchors {
+			if anchor.IP.To4() == nil {
+				errorf("-anchor: only IPv4 blocks are supported, got %s\n", anchor)
+				return ExitBadArgs
+			}
+		}
+	}
+	if *utilizationThreshold < 0 || *utilizationThreshold > 100 {
+		errorf("-utilization-threshold must be between 0 and 100\n")
+		return ExitBadArgs
+	}
+	if *head < 0 {
+		errorf("-head must not be negative\n")
+		return ExitBadArgs
+	}
+	if *sample < 0 {
+		errorf("-sample must not be negative\n")
+		return ExitBadArgs
+	}
+	if *limit < 0 {
+		errorf("-limit must not be negative\n")
+		return ExitBadArgs
+	}
+	if *limit > 0 && (*hostname != "" || *pcapPath != "" || *ipRange != "") {
+		errorf("-limit cannot be combined with -hostname, -pcap or -range\n")
+		return ExitBadArgs
+	}
+	if *alertPrefix < 0 || *alertPrefix > 32 {
+		errorf("-alert-prefix must be between 0 and 32\n")
+		return ExitBadArgs
+	}
+	if *split < 0 || *split > 32 || *minPrefix < 0 || *minPrefix > 32 || *maxPrefix < 0 || *maxPrefix > 32 {
+		errorf("-split, -min-prefix and -max-prefix must be between 0 and 32\n")
+		return ExitBadArgs
+	}
+	if *split == 0 && (*minPrefix != 0 || *maxPrefix != 32) {
+		errorf("-min-prefix and -max-prefix require -split\n")
+		return ExitBadArgs
+	}
+	if *trimPercentile < 0 || *trimPercentile >= 50 {
+		errorf("-trim-percentile must be at least 0 and lower than 50\n")
+		return ExitBadArgs
+	}
+	if *align != 0 && !slices.Contains(ipv6Alignments, *align) {
+		errorf("-align must be one of 48, 56 or 64\n")
+		return ExitBadArgs
+	}
+	if *floorPrefix < 0 || *floorPrefix > 32 {
+		errorf("-floor-prefix must be between 0 and 32\n")
+		return ExitBadArgs
+	}
+	if *floorPrefix > 0 && *minimal {
+		errorf("-floor-prefix cannot be combined with -minimal\n")
+		return ExitBadArgs
+	}
+	if (*asnMapPath == "") != (*asn == "") {
+		errorf("-asn and -asn-map must be used together\n")
+		return ExitBadArgs
+	}
+	var asnFilter uint32
+	if *asn != "" {
+		var err error
+		if asnFilter, err = parseASN(*asn); err != nil {
+			errorf("-asn: %v\n", err)
+			return ExitBadArgs
+		}
+	}
+	if (*formats == "") != (*outTemplate == "") {
+		errorf("-formats and -out must be used together\n")
+		return ExitBadArgs
+	}
+	var outFormats []string
+	if *formats != "" {
+		if *jsonOutput || *jsonl || *emit != "" || *count || *prefixOnly || offsetFrom.IPNet != nil || *showContext {
+			errorf("-formats cannot be combined with -json, -jsonl, -emit, -count, -prefix-only, -offset-from or -context\n")
+			return ExitBadArgs
+		}
+		var err error
+		if outFormats, err = parseFormats(*formats); err != nil {
+			errorf("-formats: %v\n", err)
+			return ExitBadArgs
+		}
+	}
+	if (*inPlace || *keepComments || *reportDuplicates) && *summarizePath == "" {
+		errorf("-i, -keep-comments and -report-duplicates require -summarize-file\n")
+		return ExitBadArgs
+	}
+	if *useSyslog {
+		var err error
+		if sysLog, err = dialSyslog(*syslogTag); err != nil {
+			errorf("Error connecting to syslog: %v\n", err)
+			return ExitFailure
+		}
+		lines := &syslogLines{send: sysLog.Info}
+		defer func() {
+			lines.Flush()
+			sysLog.Close()
+		}()
+		stdout = io.MultiWriter(stdout, lines)
+	}
+	if *noNewline {
+		stdout = &newlineTrimmer{w: stdout}
+	}
+	if *printJSONSchema {
+		fmt.Fprint(stdout, jsonSchema)
+		return ExitOK
+	}
+	if *summarizePath != "" {
+		summarizeOpts := summarizeOptions{inPlace: *inPlace, keepComments: *keepComments}
+		if *reportDuplicates {
+			summarizeOpts.duplicates = stderr
+		}
+		if err := summarizeFile(*summarizePath, summarizeOpts, stdout); err != nil {
+			errorf("Error summarizing %s: %v\n", *summarizePath, err)
+			return ExitFailure
+		}
+		return ExitOK
+	}
+
+	if *normalize {
+		nets, normalized, err := normalizeCIDRs(stdin, parseOptions{debug: *debug, stderr: stderr})
+		if err != nil {
+			errorf("Error reading input: %v\n", err)
+			return ExitFailure
+		}
+		if len(nets) == 0 {
+			if *allowEmpty {
+				return ExitOK
+			}
+			errorf("No valid CIDRs provided.\n")
+			return ExitNoInput
+		}
+		for _, ipnet := range nets {
+			fmt.Fprintln(stdout, ipnet)
+		}
+		fmt.Fprintf(stderr, "Normalized %d of %d CIDRs.\n", normalized, len(nets))
+		return ExitOK
+	}
+
+	if *mrtInput {
+		nets, skippedIPv6, err := readMRTPrefixes(stdin, parseOptions{debug: *debug, stderr: stderr})
+		if err != nil {
+			errorf("Error reading input: %v\n", err)
+			return ExitFailure
+		}
+		if skippedIPv6 > 0 {
+			fmt.Fprintf(stderr, "Skipped %d IPv6 prefixes: only IPv4 prefixes can be merged.\n", skippedIPv6)
+		}
+		if len(nets) == 0 {
+			if *allowEmpty {
+				return ExitOK
+			}
+			errorf("No valid prefixes provided.\n")
+			return ExitNoInput
+		}
+		for _, ipnet := range mergeCIDRs(nets) {
+			fmt.Fprintln(stdout, ipnet)
+		}
+		return ExitOK
+	}
+
+	if *combine {
+		if flags.NArg() != 2 {
+			errorf("-combine requires exactly two CIDR arguments\n")
+			return ExitBadArgs
+		}
+		var nets [2]*net.IPNet
+		for i, arg := range flags.Args() {
+			_, ipnet, err := parseCIDR(arg)
+			if err != nil {
+				errorf("-combine: %v\n", err)
+				return ExitBadArgs
+			}
+			nets[i] = ipnet
+		}
+		ipnet, err := combineCIDRs(nets[0], nets[1])
+		if err != nil {
+			errorf("Error combining CIDRs: %v\n", err)
+			return ExitFailure
+		}
+		fmt.Fprintln(stdout, ipnet)
+		return ExitOK
+	}
+
+	if *hosts != 0 {
+		prefixLen := prefixForHosts(*hosts)
+		if *hosts < 0 || prefixLen < 0 {
+			errorf("-hosts must be between 1 and %d\n", usableHosts(0))
+			return ExitBadArgs
+		}
+		network := exampleHostsNetwork
+		if hostsNetwork.IPNet != nil {
+			if ones, _ := hostsNetwork.Mask.Size(); hostsNetwork.IP.To4() == nil || ones > prefixLen {
+				errorf("-hosts-network %s cannot hold a /%d\n", hostsNetwork.IPNet, prefixLen)
+				return ExitBadArgs
+			}
+			network = hostsNetwork.IP
+		}
+		example := &net.IPNet{IP: network.Mask(net.CIDRMask(prefixLen, 32)), Mask: net.CIDRMask(prefixLen, 32)}
+		fmt.Fprintf(stdout, "/%d (usable hosts: %d), e.g. %s\n", prefixLen, usableHosts(prefixLen), example)
+		return ExitOK
+	}
+
+	if *mergeAdjacentRanges {
+		ranges, err := readRanges(stdin)
+		if err != nil {
+			errorf("Error reading input: %v\n", err)
+			return ExitFailure
+		}
+		if len(ranges) == 0 {
+			if *allowEmpty {
+				return ExitOK
+			}
+			errorf("No ranges provided.\n")
+			return ExitNoInput
+		}
+		for _, r := range mergeRanges(ranges) {
+			fmt.Fprintf(stdout, "%s-%s\n", uint32ToIP(r[0]), uint32ToIP(r[1]))
+		}
+		return ExitOK
+	}
+
+	sources := 0
+	for _, source := range []string{*hostname, *url, *pcapPath, *ipRange} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		errorf("only one of -hostname, -url, -pcap and -range can be used\n")
+		return ExitBadArgs
+	}
+	if *batch && sources > 0 {
+		errorf("-batch cannot be used with -hostname, -url, -pcap or -range\n")
+		return ExitBadArgs
+	}
+	if *allowMissingEdges && !*requireContiguous {
+		errorf("-allow-missing-edges requires -require-contiguous\n")
+		return ExitBadArgs
+	}
+	if *grandTotal && !*batch {
+		errorf("-grand-total requires -batch\n")
+		return ExitBadArgs
+	}
+	if *resolveStdin && (sources > 0 || *batch || *csvInput || *jsonl) {
+		errorf("-resolve-stdin cannot be combined with -hostname, -url, -pcap, -range, -batch, -csv-input or -jsonl\n")
+		return ExitBadArgs
+	}
+	if *countBlocks && *ipRange == "" {
+		errorf("-count-blocks requires -range\n")
+		return ExitBadArgs
+	}
+	if *watch < 0 || (*watch > 0 && *hostname == "") {
+		errorf("-watch requires -hostname and a positive interval\n")
+		return ExitBadArgs
+	}
+	if *watch > 0 {
+		ticker := time.NewTicker(*watch)
+		defer ticker.Stop()
+		watchHostname(*hostname, ticker.C, stdout, stderr, errorf)
+		return ExitOK
+	}
+
+	outOpts := outputOptions{jsonl: *jsonl, json: *jsonOutput, count: *count, prefixOnly: *prefixOnly, offsetFrom: offsetFrom.IPNet, indent: *indent, canonical: *canonical, emit: *emit, setName: *setName, via: gateway, group: *group}
+	if *labelsPath != "" {
+		labels, err := loadLabels(*labelsPath)
+		if err != nil {
+			errorf("Error reading labels %s: %v\n", *labelsPath, err)
+			return ExitFailure
+		}
+		// An empty file still means every subnet needs a label.
+		outOpts.labels = append([]string{}, labels...)
+		outOpts.allowUnlabeled = *allowUnlabeled
+	}
+	outputs := []output{{w: stdout, opts: outOpts}}
+	if *formats != "" {
+		var closeOutputs func() error
+		outputs, closeOutputs, err = openOutputs(outFormats, *outTemplate, outOpts)
+		if err != nil {
+			errorf("Error creating output files: %v\n", err)
+			return ExitFailure
+		}
+		defer func() {
+			if err := closeOutputs(); err != nil {
+				errorf("Error writing output: %v\n", err)
+				if code == ExitOK {
+					code = ExitFailure
+				}
+			}
+		}()
+	}
+
+	opts := parseOptions{
+		debug:             *debug,
+		stderr:            stderr,
+		cidrHost:          *cidrHost,
+		allowZone:         *allowZone,
+		jsonl:             *jsonl,
+		numeric:           *numeric,
+		nmap:              *nmapInput,
+		extract:           *extract,
+		maskInput:         *maskInput,
+		logFormat:         *logFormat,
+		base:              base.IPNet,
+		rejectUnspecified: *rejectUnspecified,
+		alertPrefix:       *alertPrefix,
+		asn:               asnFilter,
+	}
+	if *limit > 0 {
+		opts.limit = &inputLimit{max: *limit}
+	}
+	if *progress && !*quietErrors {
+		opts.progress = newProgressReporter(stderr, 100000, time.Second)
+	}
+	if *asnMapPath != "" {
+		table, err := loadASNMap(*asnMapPath)
+		if err != nil {
+			errorf("Error reading ASN map %s: %v\n", *asnMapPath, err)
+			return ExitFailure
+		}
+		opts.asns = table
+	}
+	var groups [][]net.IP
 
 	if *hostname != "" {
-		ips, err = resolveHostname(*hostname)
+		ips, err := resolveHostname(*hostname)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error resolving hostname %s: %v\n", *hostname, err)
-			return
+			errorf("Error resolving hostname %s: %v\n", *hostname, err)
+			return ExitResolveFail
 		}
 		if *debug {
-			debugLog(fmt.Sprintf("Resolved IPs for %s: %v", *hostname, ips))
+			debugLog(stderr, fmt.Sprintf("Resolved IPs for %s: %v", *hostname, ips))
+		}
+		groups = [][]net.IP{opts.filterIPs(ips)}
+	} else if *url != "" {
+		ips, err := fetchIPs(*url, *timeout, opts)
+		if err != nil {
+			errorf("Error fetching %s: %v\n", *url, err)
+			return ExitFailure
 		}
+		groups = [][]net.IP{ips}
+	} else if *pcapPath != "" {
+		f, err := os.Open(*pcapPath)
+		if err != nil {
+			errorf("Error reading pcap: %v\n", err)
+			return ExitFailure
+		}
+		ips, err := readPcapIPs(f)
+		f.Close()
+		if err != nil {
+			errorf("Error reading pcap %s: %v\n", *pcapPath, err)
+			return ExitFailure
+		}
+		if *debug {
+			debugLog(stderr, fmt.Sprintf("Read %d IPs from %s", len(ips), *pcapPath))
+		}
+		groups = [][]net.IP{opts.filterIPs(ips)}
+	} else if *ipRange != "" {
+		start, end, err := parseRange(*ipRange)
+		if err != nil {
+			errorf("Error parsing range: %v\n", err)
+			return ExitBadArgs
+		}
+		if *countBlocks {
+			fmt.Fprintln(stdout, countRangeBlocks(start, end))
+			return ExitOK
+		}
+		if *minimal {
+			var blocks []*net.IPNet
+			for _, block := range rangeToCIDRs(start, end) {
+				blocks = append(blocks, &block)
+			}
+			if *verify {
+				if err := verifyRanges(blocks, [][2]uint32{{start, end}}); err != nil {
+					errorf("Error verifying blocks: %v\n", err)
+					return ExitFailure
+				}
+			}
+			blocks, omitted := headBlocks(blocks, *head)
+			if omitted > 0 {
+				fmt.Fprintf(stderr, "Omitted %d more blocks because of -head.\n", omitted)
+			}
+			if err := writeOutputs(outputs, blocks); err != nil {
+				errorf("Error writing output: %v\n", err)
+				return ExitFailure
+			}
+			return ExitOK
+		}
+		groups = [][]net.IP{{uint32ToIP(start), uint32ToIP(end)}}
 	} else {
 		if *debug {
-			debugLog("Enter IPs, one per line. Press Ctrl+D (Unix) or Ctrl+Z (Windows) to end:")
+			debugLog(stderr, "Enter IPs, one per line. Press Ctrl+D (Unix) or Ctrl+Z (Windows) to end:")
+		}
+		if *stdinTimeout > 0 {
+			stdin = newTimeoutReader(stdin, *stdinTimeout)
+		}
+		var err error
+		if *auto && !*jsonl && !*numeric {
+			var format string
+			if format, stdin, err = sniffFormat(stdin); err != nil {
+				errorf("Error reading input: %v\n", err)
+				return ExitFailure
+			}
+			if *debug {
+				debugLog(stderr, fmt.Sprintf("Detected input format: %s", format))
+			}
+			opts.jsonl = format == formatJSON
+			opts.numeric = format == formatInteger
+		}
+		if *resolveStdin {
+			var ips []net.IP
+			ips, err = resolveHostnames(stdin, opts)
+			groups = [][]net.IP{ips}
+		} else if *csvInput {
+			var ips []net.IP
+			ips, err = parseIPsFromCSV(stdin, *csvColumn, *csvHeader, opts)
+			groups = [][]net.IP{ips}
+		} else if *batch {
+			groups, err = parseGroupsFromReader(stdin, opts)
+		} else {
+			var ips []net.IP
+			ips, err = parseIPsFromReader(stdin, opts)
+			groups = [][]net.IP{ips}
 		}
-		ips, err = parseIPsFromReader(os.Stdin, *debug)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
-			return
+			errorf("Error reading input: %v\n", err)
+			if errors.Is(err, errInputTimeout) {
+				return ExitNoInput
+			}
+			return ExitFailure
 		}
 	}
 
-	if len(ips) == 0 {
-		fmt.Fprintf(os.Stderr, "No valid IPs provided.\n")
-		return
+	if len(includes) > 0 {
+		included, err := readIncludeFiles(includes, opts)
+		if err != nil {
+			errorf("Error reading include file: %v\n", err)
+			return ExitFailure
+		}
+		for i := range groups {
+			groups[i] = append(groups[i], included...)
+		}
 	}
+	opts.limit.warn(stderr)
 
-	cidr, err := calculateCIDR(ips)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error calculating CIDR: %v\n", err)
-		return
+	// aggregate turns a group of IPs into the enclosing block and the blocks
+	// to print in its place.
+	aggregate := func(ips []net.IP) (*net.IPNet, []*net.IPNet, error) {
+		ipnet, err := calculateIPNet(ips)
+		if err != nil {
+			return nil, nil, err
+		}
+		if *align > 0 {
+			ipnet = alignIPv6(ipnet, *align)
+		}
+		blocks := []*net.IPNet{ipnet}
+		if *minimal {
+			if blocks, err = anchoredMinimalCIDRs(ips, anchors); err != nil {
+				return nil, nil, err
+			}
+		}
+		if ones, _ := ipnet.Mask.Size(); *floorPrefix > 0 && ones < *floorPrefix {
+			if blocks, err = floorCIDRs(ips, *floorPrefix); err != nil {
+				return nil, nil, err
+			}
+		}
+		if *dropBogons {
+			var routable []*net.IPNet
+			for _, block := range blocks {
+				routable = append(routable, subtractCIDRs(block, bogons)...)
+			}
+			blocks = routable
+		}
+		if *split > 0 {
+			if blocks, err = splitBlocks(blocks, *split); err != nil {
+				return nil, nil, err
+			}
+			if blocks, err = clampPrefixes(blocks, *minPrefix, *maxPrefix); err != nil {
+				return nil, nil, err
+			}
+		}
+		return ipnet, blocks, nil
+	}
+	// Every random choice goes through rng so that -seed reproduces the
+	// whole run.
+	if *seed == 0 {
+		*seed = randomSeed()
+	}
+	rng := rand.New(rand.NewSource(*seed))
+
+	printed := 0
+	var grand runningBounds
+	for i, ips := range groups {
+		if len(ips) == 0 {
+			if *batch {
+				fmt.Fprintf(stderr, "No valid IPs provided in group %d.\n", i+1)
+			}
+			continue
+		}
+
+		total := len(ips)
+		if *sample > 0 && total > *sample {
+			ips = sampleIPs(ips, *sample, rng)
+			fmt.Fprintf(stderr, "Estimating from a sample of %d of %d IPs: the true block may be wider.\n", *sample, total)
+		}
+		if !*noDedup {
+			ips = uniqueIPs(ips)
+		}
+		if *debug {
+			if *noDedup {
+				debugLog(stderr, fmt.Sprintf("%d IPs, duplicates included", len(ips)))
+			} else {
+				debugLog(stderr, fmt.Sprintf("%d unique IPs", len(ips)))
+			}
+			if *dumpUint {
+				dumpUint32s(stderr, ips)
+			}
+		}
+
+		if *trimPercentile > 0 {
+			full, err := calculateIPNet(ips)
+			if err != nil {
+				errorf("Error calculating CIDR: %v\n", err)
+				return ExitFailure
+			}
+			var trimmed int
+			ips, trimmed = trimOutliers(ips, *trimPercentile)
+			if trimmed > 0 {
+				tight, _ := calculateIPNet(ips)
+				fmt.Fprintf(stderr, "Trimmed %d outliers, narrowing %s to %s.\n", trimmed, full, tight)
+			}
+		}
+
+		if *maxGap > 0 {
+			if lo, hi, gap, found := largestGap(ips); found && uint64(gap) > uint64(*maxGap) {
+				errorf("Error checking gaps: %s and %s are %d addresses apart, more than -max-gap %d\n", lo, hi, gap, *maxGap)
+				return ExitFailure
+			}
+		}
+
+		ipnet, blocks, err := aggregate(ips)
+		if err != nil {
+			errorf("Error calculating CIDR: %v\n", err)
+			return ExitFailure
+		}
+		if *strictAlign {
+			if note := alignmentNote(ips, ipnet); note != "" {
+				fmt.Fprintln(stderr, note)
+			}
+		}
+		if *explainBitsFlag {
+			var bounds runningBounds
+			for _, ip := range ips {
+				bounds.add(ip)
+			}
+			if bounds.seen {
+				fmt.Fprint(stderr, explainBits(bounds.min, bounds.max))
+			}
+		}
+		if *requireContiguous {
+			start, end, found, err := firstGap(ipnet, ips, *allowMissingEdges)
+			if err != nil {
+				errorf("Error checking contiguity: %v\n", err)
+				return ExitFailure
+			}
+			if found {
+				errorf("Error checking contiguity: the IPs are not contiguous in %s, the first gap is %s-%s\n", ipnet, uint32ToIP(start), uint32ToIP(end))
+				return ExitFailure
+			}
+		}
+		if *dropBogons && len(blocks) == 0 {
+			fmt.Fprintf(stderr, "No routable blocks left in %s after dropping bogons.\n", ipnet)
+		}
+		if *selfCheck {
+			if err := checkOrderIndependent(ips, blocks, aggregate, rng); err != nil {
+				errorf("Self-check failed: %v\n", err)
+				return ExitFailure
+			}
+		}
+
+		if *verify {
+			if err := VerifyCover(blocks, ips); err != nil {
+				errorf("Error verifying blocks: %v\n", err)
+				return ExitFailure
+			}
+		}
+		if *vpcCheck {
+			for _, block := range blocks {
+				if err := checkVPC(block); err != nil {
+					errorf("Error checking VPC constraints: %v\n", err)
+					return ExitFailure
+				}
+			}
+		}
+
+		blocks, omitted := headBlocks(blocks, *head)
+		if omitted > 0 {
+			fmt.Fprintf(stderr, "Omitted %d more blocks because of -head.\n", omitted)
+		}
+		if *showContext {
+			err = writeBlocksWithContext(stdout, blocks, outOpts)
+		} else {
+			err = writeOutputs(outputs, blocks)
+		}
+		if err != nil {
+			errorf("Error writing output: %v\n", err)
+			return ExitFailure
+		}
+		if *visualize {
+			for _, block := range blocks {
+				bar, err := visualizeBlock(block, ips, visualizeWidth, os.Getenv("NO_COLOR") == "")
+				if err != nil {
+					errorf("Error visualizing %s: %v\n", block, err)
+					return ExitFailure
+				}
+				fmt.Fprintf(stderr, "%s %s\n", block, bar)
+			}
+		}
+		if compareTo.IPNet != nil {
+			fmt.Fprintln(stderr, compareToReference(ipnet, compareTo.IPNet))
+		}
+		if *classify {
+			fmt.Fprintln(stderr, classifyBlock(ipnet))
+		}
+		if *compareStrategiesFlag {
+			comparison, err := compareStrategies(ips)
+			if err != nil {
+				errorf("Error comparing strategies: %v\n", err)
+				return ExitFailure
+			}
+			fmt.Fprintln(stderr, comparison)
+		}
+		if *per24 {
+			subnets, err := per24Counts(ips)
+			if err != nil {
+				errorf("Error counting IPs per /24: %v\n", err)
+				return ExitFailure
+			}
+			for _, s := range subnets {
+				fmt.Fprintf(stderr, "%s %d\n", s.block, s.count)
+			}
+		}
+		if *spread {
+			s, err := spreadOf(ipnet, ips)
+			if err != nil {
+				errorf("Error measuring spread: %v\n", err)
+				return ExitFailure
+			}
+			fmt.Fprintln(stderr, s)
+		}
+		if *countUsableInputs {
+			usable, edges := usableInputs(ipnet, ips)
+			fmt.Fprintf(stderr, "%d of %d input IPs are usable hosts in %s\n", usable, len(ips), ipnet)
+			for _, ip := range edges {
+				fmt.Fprintf(stderr, "Warning: %s is the network or broadcast address of %s\n", ip, ipnet)
+			}
+		}
+		if *utilizationThreshold > 0 && !*minimal {
+			if used := utilization(len(uniqueIPs(ips)), ipnet); used < *utilizationThreshold {
+				fmt.Fprintf(stderr, "Warning: the input IPs fill only %.2f%% of %s, below -utilization-threshold %g%%; -minimal would print a tighter set of blocks\n",
+					used, ipnet, *utilizationThreshold)
+			}
+		}
+		if *summary {
+			fmt.Fprintln(stderr, summaryLine(total, len(uniqueIPs(ips)), ipnet))
+		}
+		for _, ip := range ips {
+			grand.add(ip)
+		}
+		printed++
 	}
 
-	fmt.Println(cidr)
+	if printed == 0 {
+		if *allowEmpty {
+			return ExitOK
+		}
+		errorf("No valid IPs provided.\n")
+		return ExitNoInput
+	}
+	if *grandTotal && grand.seen {
+		if err := writeOutputs(outputs, []*net.IPNet{grand.cidr()}); err != nil {
+			errorf("Error writing output: %v\n", err)
+			return ExitFailure
+		}
+	}
+	return ExitOK
 }
 
-// resolveHostname resolves a hostname to its IP addresses.
+// lookupIP is the resolver used by resolveHostname. Tests replace it to avoid
+// depending on DNS.
+var lookupIP = net.LookupIP
+
+// resolveHostname resolves a hostname to its IP addresses. Resolvers return
+// them in no particular order, so they are sorted to keep the debug output
+// the same from one run to the next.
 func resolveHostname(hostname string) ([]net.IP, error) {
-	return net.LookupIP(hostname)
+	ips, err := lookupIP(hostname)
+	if err != nil {
+		return nil, err
+	}
+	sortByIP(ips, func(ip net.IP) net.IP { return ip })
+	return ips, nil
 }
 
-// parseIPsFromReader reads IP addresses from an io.Reader, one per line.
-func parseIPsFromReader(reader io.Reader, debug bool) ([]net.IP, error) {
+// resolveHostnames reads one hostname per line and returns the IPs of all of
+// them. Hostnames that fail to resolve are invalid lines.
+func resolveHostnames(reader io.Reader, opts parseOptions) ([]net.IP, error) {
 	var ips []net.IP
 	scanner := bufio.NewScanner(reader)
 	for scanner.Scan() {
-		ip := net.ParseIP(scanner.Text())
-		if ip == nil {
-			if debug {
-				debugLog(fmt.Sprintf("Invalid IP: %s", scanner.Text()))
-			}
+		hostname := strings.TrimSpace(scanner.Text())
+		if hostname == "" {
+			continue
+		}
+		resolved, err := resolveHostname(hostname)
+		if err != nil {
+			opts.invalid(fmt.Sprintf("Unresolvable hostname: %s: %v", hostname, err))
 			continue
 		}
-		ips = append(ips, ip)
+		if opts.debug {
+			debugLog(opts.stderr, fmt.Sprintf("Resolved IPs for %s: %v", hostname, resolved))
+		}
+		if kept := opts.filterIPs(resolved); opts.limit.take(len(kept)) {
+			ips = append(ips, kept...)
+		}
 	}
 	if err := scanner.Err(); err != nil {
 		return nil, err
@@ -77,34 +1028,480 @@ func parseIPsFromReader(reader io.Reader, debug bool) ([]net.IP, error) {
 	return ips, nil
 }
 
+// readIncludeFiles reads the IPs of each of the -include files, parsed like
+// the lines of stdin.
+func readIncludeFiles(paths []string, opts parseOptions) ([]net.IP, error) {
+	// The progress reporter is about the main input.
+	opts.progress = nil
+	var ips []net.IP
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		included, err := parseIPsFromReader(f, opts)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		ips = append(ips, included...)
+	}
+	return ips, nil
+}
+
+// parseOptions controls how parseIPsFromReader interprets its input.
+type parseOptions struct {
+	debug  bool
+	stderr io.Writer // Receives debug output.
+
+	// cidrHost makes lines in CIDR notation contribute their host address
+	// rather than the first and last addresses of the block they denote.
+	cidrHost bool
+
+	// allowZone makes IPv6 addresses carrying a zone, such as fe80::1%eth0,
+	// valid. The zone isn't part of the address, so it is dropped.
+	allowZone bool
+
+	// jsonl makes each line a JSON object carrying the address in its "ip"
+	// field rather than a bare address.
+	jsonl bool
+
+	// numeric makes each line an IPv4 address written as a decimal integer
+	// rather than in dotted notation.
+	numeric bool
+
+	// extract makes each line free-form text, from which every IP address
+	// is picked out.
+	extract bool
+
+	// logFormat, when set, makes each line a web server log line in one of
+	// logFormats, of which only the client address is read.
+	logFormat string
+
+	// maskInput makes each line a network address followed by its dotted
+	// netmask, such as "192.168.1.0 255.255.255.0", read as the CIDR they
+	// denote.
+	maskInput bool
+
+	// nmap makes each line a list of nmap targets, which may use octet
+	// ranges such as 192.168.1.1-50.
+	nmap bool
+
+	// base, when set, is the network every input IP must belong to. Lines
+	// with IPs outside of it are invalid.
+	base *net.IPNet
+
+	// rejectUnspecified makes lines with the unspecified address 0.0.0.0 or
+	// :: invalid, so that they don't widen the block down to /0.
+	rejectUnspecified bool
+
+	// limit, when set, caps the number of IPs kept in memory, across all
+	// the inputs read with these options.
+	limit *inputLimit
+
+	// alertPrefix, when non-zero, prints a warning to stderr as soon as the
+	// block enclosing the IPs read so far becomes shorter than /alertPrefix.
+	alertPrefix int
+
+	// asns, when set, attributes each input IP to an autonomous system.
+	// Lines with IPs not attributed to asn are invalid.
+	asns asnTable
+	asn  uint32
+
+	// progress, when set, is told about every line read.
+	progress *progressReporter
+
+	// lineNum is the number of the line being parsed, used in error
+	// messages when non-zero. parseIPsFromReader counts on from the value it
+	// is given, so that callers can account for lines read beforehand.
+	lineNum int
+}
+
+// inputLimit caps the number of IPs kept by -limit, shared by all the input
+// sources of a run.
+type inputLimit struct {
+	max     int
+	kept    int
+	dropped int
+}
+
+// take reports whether n more IPs fit under the limit, counting them as kept
+// or dropped. Once IPs have been dropped, all later ones are too, so that the
+// IPs kept are the first ones read. A nil limit takes everything.
+func (l *inputLimit) take(n int) bool {
+	if l == nil {
+		return true
+	}
+	if l.dropped > 0 || l.kept+n > l.max {
+		l.dropped += n
+		return false
+	}
+	l.kept += n
+	return true
+}
+
+// warn prints a warning to w when IPs were dropped.
+func (l *inputLimit) warn(w io.Writer) {
+	if l != nil && l.dropped > 0 {
+		fmt.Fprintf(w, "Warning: kept the first %d IPs and dropped %d more because of -limit\n", l.kept, l.dropped)
+	}
+}
+
+// parseIPsFromReader reads IP addresses from an io.Reader, one per line. A
+// line in CIDR notation contributes the network and broadcast addresses of
+// its block, or only its host address when opts.cidrHost is set.
+func parseIPsFromReader(reader io.Reader, opts parseOptions) ([]net.IP, error) {
+	groups, err := scanIPs(reader, opts, false)
+	if err != nil || len(groups) == 0 {
+		return nil, err
+	}
+	return groups[0], nil
+}
+
+// parseGroupsFromReader reads groups of IP addresses separated by blank
+// lines. Each group is parsed as by parseIPsFromReader; a group whose lines
+// are all invalid is returned empty.
+func parseGroupsFromReader(reader io.Reader, opts parseOptions) ([][]net.IP, error) {
+	return scanIPs(reader, opts, true)
+}
+
+// scanIPs reads the lines of reader into groups of IPs, parsing each line as
+// it is read. With batch, blank lines separate the groups; otherwise all the
+// IPs form a single group. No group is returned for an empty input.
+func scanIPs(reader io.Reader, opts parseOptions, batch bool) ([][]net.IP, error) {
+	var groups [][]net.IP
+	var ips []net.IP
+	inGroup := false
+	var bounds runningBounds
+	alerted := false
+	flush := func() {
+		if inGroup {
+			groups = append(groups, ips)
+			opts.progress.reset()
+		}
+		ips, inGroup = nil, false
+		bounds, alerted = runningBounds{}, false
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		opts.lineNum++
+		line := scanner.Text()
+		if batch && strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		inGroup = true
+		opts.progress.line()
+		lineIPs, ok := parseLine(line, opts)
+		if !ok || !opts.limit.take(len(lineIPs)) {
+			continue
+		}
+		ips = append(ips, lineIPs...)
+
+		if opts.alertPrefix == 0 || alerted {
+			continue
+		}
+		for _, ip := range lineIPs {
+			bounds.add(ip)
+			if bounds.prefixLen() < opts.alertPrefix {
+				fmt.Fprintf(opts.stderr, "Warning: %s broadens the enclosing block to %s, shorter than /%d\n", ip, bounds.cidr(), opts.alertPrefix)
+				alerted = true
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return groups, nil
+}
+
+// parseLine returns the IPs contributed by a single input line. It returns
+// false when the line holds neither an IP nor a CIDR.
+func parseLine(line string, opts parseOptions) ([]net.IP, bool) {
+	if opts.jsonl {
+		var ok bool
+		line, ok = parseJSONLRecord(line)
+		if !ok {
+			opts.invalid(fmt.Sprintf("Invalid JSON: %s", line))
+			return nil, false
+		}
+	}
+	if opts.numeric {
+		u, err := strconv.ParseUint(strings.TrimSpace(line), 10, 32)
+		if err != nil {
+			opts.invalid(fmt.Sprintf("Invalid integer: %s", line))
+			return nil, false
+		}
+		ips := []net.IP{uint32ToIP(uint32(u))}
+		return ips, opts.accept(ips, line)
+	}
+	if opts.extract {
+		// Most lines of free-form text mention no address, which doesn't
+		// make them invalid.
+		ips := opts.filterIPs(extractIPs(line))
+		return ips, len(ips) > 0
+	}
+	if opts.logFormat != "" {
+		addr, ok := logClientAddress(line)
+		if !ok {
+			opts.invalid(fmt.Sprintf("Missing client address: %s", line))
+			return nil, false
+		}
+		line = addr
+	}
+	if opts.maskInput {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			opts.invalid(fmt.Sprintf("Not a network and mask pair: %s", line))
+			return nil, false
+		}
+		prefixLen, err := maskToPrefix(net.ParseIP(fields[1]))
+		if err != nil {
+			opts.invalid(fmt.Sprintf("Invalid mask: %s: %v", line, err))
+			return nil, false
+		}
+		line = fmt.Sprintf("%s/%d", fields[0], prefixLen)
+	}
+	if opts.nmap {
+		ips, ok := parseNmapTargets(line, opts)
+		if !ok {
+			return nil, false
+		}
+		return ips, opts.accept(ips, line)
+	}
+
+	ips, ok := parseAddress(line, opts)
+	if !ok {
+		return nil, false
+	}
+	return ips, opts.accept(ips, line)
+}
+
+// parseAddress returns the IPs denoted by an address or a CIDR.
+func parseAddress(line string, opts parseOptions) ([]net.IP, bool) {
+	if opts.allowZone {
+		addr, zone, ok := cutZone(line)
+		if ok {
+			if zone == "" {
+				opts.invalid(fmt.Sprintf("Empty zone: %s", line))
+				return nil, false
+			}
+			if ip := net.ParseIP(strings.SplitN(addr, "/", 2)[0]); ip == nil || ip.To4() != nil {
+				opts.invalid(fmt.Sprintf("Zone on a non-IPv6 address: %s", line))
+				return nil, false
+			}
+			line = addr
+		}
+	}
+
+	if strings.Contains(line, "/") {
+		ip, ipnet, err := parseCIDR(line)
+		if err != nil {
+			opts.invalid(atLine(opts.lineNum, fmt.Sprintf("Invalid CIDR: %s: %v", line, err)))
+			return nil, false
+		}
+		if opts.cidrHost {
+			return []net.IP{normalizeIP(ip)}, true
+		}
+		return []net.IP{normalizeIP(ipnet.IP), normalizeIP(broadcastIP(ipnet))}, true
+	}
+
+	ip := net.ParseIP(line)
+	if ip == nil {
+		opts.invalid(fmt.Sprintf("Invalid IP: %s", line))
+		return nil, false
+	}
+	return []net.IP{normalizeIP(ip)}, true
+}
+
+// cutZone removes the zone from an IPv6 address such as fe80::1%eth0 or
+// fe80::1%eth0/64, returning the address without it and the zone. It returns
+// false when there is no zone.
+func cutZone(line string) (addr, zone string, ok bool) {
+	before, after, ok := strings.Cut(line, "%")
+	if !ok {
+		return line, "", false
+	}
+	zone, prefix, hasPrefix := strings.Cut(after, "/")
+	if hasPrefix {
+		return before + "/" + prefix, zone, true
+	}
+	return before, zone, true
+}
+
+// accept reports whether the IPs read from line pass the validation options.
+func (o parseOptions) accept(ips []net.IP, line string) bool {
+	if o.rejectUnspecified {
+		for _, ip := range ips {
+			if ip.IsUnspecified() {
+				o.invalid(fmt.Sprintf("Unspecified address: %s", line))
+				return false
+			}
+		}
+	}
+	if o.base != nil {
+		for _, ip := range ips {
+			if !o.base.Contains(ip) {
+				o.invalid(fmt.Sprintf("Outside base network %s: %s", o.base, line))
+				return false
+			}
+		}
+	}
+	if o.asns != nil {
+		for _, ip := range ips {
+			if asn, ok := o.asns.lookup(ip); !ok || asn != o.asn {
+				o.invalid(fmt.Sprintf("Not announced by AS%d: %s", o.asn, line))
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// filterIPs returns the IPs that pass the validation options. It applies to
+// IPs that don't come from parsed lines, such as resolved hostnames.
+func (o parseOptions) filterIPs(ips []net.IP) []net.IP {
+	var kept []net.IP
+	for _, ip := range ips {
+		ip = normalizeIP(ip)
+		if o.accept([]net.IP{ip}, ip.String()) {
+			kept = append(kept, ip)
+		}
+	}
+	return kept
+}
+
+// atLine prefixes msg with "line N: " to tell which input line it is about,
+// unless lineNum is 0 for an unknown line.
+func atLine(lineNum int, msg string) string {
+	if lineNum == 0 {
+		return msg
+	}
+	return fmt.Sprintf("line %d: %s", lineNum, msg)
+}
+
+// invalid handles an input that is skipped, logging the reason under debug.
+func (o parseOptions) invalid(reason string) {
+	if o.debug {
+		debugLog(o.stderr, reason)
+	}
+}
+
+// runningBounds tracks the lowest and highest IPv4 addresses of a stream so
+// that the enclosing block is known at any point without keeping the
+// addresses around. Non-IPv4 addresses are ignored.
+type runningBounds struct {
+	min, max uint32
+	seen     bool
+}
+
+// add widens the bounds to include ip.
+func (b *runningBounds) add(ip net.IP) {
+	if ip.To4() == nil {
+		return
+	}
+	u := ipToUint32(ip)
+	if !b.seen {
+		b.min, b.max, b.seen = u, u, true
+		return
+	}
+	if u < b.min {
+		b.min = u
+	}
+	if u > b.max {
+		b.max = u
+	}
+}
+
+// prefixLen returns the prefix length of the block enclosing the bounds.
+func (b *runningBounds) prefixLen() int {
+	return calculatePrefixLength(b.min, b.max)
+}
+
+// cidr returns the block enclosing the bounds.
+func (b *runningBounds) cidr() *net.IPNet {
+	prefixLen := b.prefixLen()
+	mask := net.CIDRMask(prefixLen, 32)
+	return &net.IPNet{IP: uint32ToIP(b.min).Mask(mask), Mask: mask}
+}
+
 // calculateCIDR calculates the smallest CIDR block that contains all given IPs.
 func calculateCIDR(ips []net.IP) (string, error) {
+	ipnet, err := calculateIPNet(ips)
+	if err != nil {
+		return "", err
+	}
+	return ipnet.String(), nil
+}
+
+// calculateIPNet is like calculateCIDR but returns the block as a *net.IPNet.
+func calculateIPNet(ips []net.IP) (*net.IPNet, error) {
 	if len(ips) == 0 {
-		return "", fmt.Errorf("no IPs provided")
+		return nil, fmt.Errorf("no IPs provided")
+	}
+	// A nil IP would otherwise be taken for 0.0.0.0 or an IPv6 address.
+	if i := slices.IndexFunc(ips, func(ip net.IP) bool { return ip.To16() == nil }); i >= 0 {
+		return nil, fmt.Errorf("invalid IP at index %d", i)
 	}
 
-	// Sort IPs
-	sortedIPs := make([]net.IP, len(ips))
-	copy(sortedIPs, ips)
-	sort.Slice(sortedIPs, func(i, j int) bool {
-		return compareIPs(sortedIPs[i], sortedIPs[j]) < 0
-	})
+	// A single address, possibly repeated, is its own block.
+	if ip := singleAddress(ips); ip != nil {
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(8*len(ip), 8*len(ip))}, nil
+	}
 
-	minIP := sortedIPs[0]
-	maxIP := sortedIPs[len(sortedIPs)-1]
+	// Only the lowest and highest addresses matter, so there is no need to
+	// sort.
+	minIP, maxIP := normalizeIP(ips[0]), normalizeIP(ips[0])
+	for _, ip := range ips[1:] {
+		ip = normalizeIP(ip)
+		if len(ip) != len(minIP) {
+			return nil, fmt.Errorf("cannot aggregate IPv4 and IPv6 addresses together")
+		}
+		if bytes.Compare(ip, minIP) < 0 {
+			minIP = ip
+		}
+		if bytes.Compare(ip, maxIP) > 0 {
+			maxIP = ip
+		}
+	}
 
-	// Convert minIP and maxIP to uint32 for calculations
-	minUint := ipToUint32(minIP)
-	maxUint := ipToUint32(maxIP)
+	mask, err := maskForPrefix(commonPrefixBits(minIP, maxIP), 8*len(minIP))
+	if err != nil {
+		return nil, err
+	}
+	return &net.IPNet{IP: minIP.Mask(mask), Mask: mask}, nil
+}
 
-	// Calculate the CIDR prefix
-	prefixLen := calculatePrefixLength(minUint, maxUint)
+// singleAddress returns the normalized address when every IP in ips is the
+// same, and nil otherwise. It stops at the first differing address.
+func singleAddress(ips []net.IP) net.IP {
+	for _, ip := range ips[1:] {
+		if !ip.Equal(ips[0]) {
+			return nil
+		}
+	}
+	return normalizeIP(ips[0])
+}
 
-	// Return the CIDR block
-	return fmt.Sprintf("%s/%d", minIP.Mask(net.CIDRMask(prefixLen, 32)), prefixLen), nil
+// sortByIP sorts items by the address returned by ipOf, in the order of
+// compareIPs. The sort is stable: items with equal addresses keep their input
+// order, so metadata attached to duplicates (such as line numbers) stays in the
+// order it was read.
+func sortByIP[T any](items []T, ipOf func(T) net.IP) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return compareIPs(ipOf(items[i]), ipOf(items[j])) < 0
+	})
 }
 
 // calculatePrefixLength calculates the prefix length for a CIDR that contains both min and max IPs.
+//
+// CIDR blocks are aligned on their own size, so the result may be much shorter
+// than the span of the addresses suggests: 192.168.1.1 and 192.168.1.2 are
+// only two addresses apart but sit on both sides of a /31 boundary, so the
+// smallest block holding both is 192.168.1.0/30.
 func calculatePrefixLength(minUint, maxUint uint32) int {
 	prefixLen := 32
 	for prefixLen > 0 {
@@ -117,34 +1514,178 @@ func calculatePrefixLength(minUint, maxUint uint32) int {
 	return prefixLen
 }
 
-// debugLog prints debug messages to stderr with a yellow "debug:" prefix
-func debugLog(message string) {
+// commonPrefixBits returns the number of leading bits shared by a and b,
+// counted within the width of their family: out of 32 for IPv4 addresses and
+// out of 128 for IPv6 ones. It returns -1 when a or b isn't a valid IP, or
+// when they aren't of the same family, as those can't be aggregated together.
+func commonPrefixBits(a, b net.IP) int {
+	a16, b16 := a.To16(), b.To16()
+	if a16 == nil || b16 == nil {
+		return -1
+	}
+	isIPv4 := a.To4() != nil
+	if isIPv4 != (b.To4() != nil) {
+		return -1
+	}
+	common := calculatePrefixLength128(a16, b16)
+	if isIPv4 {
+		return common - 96
+	}
+	return common
+}
+
+// commonPrefixLength returns the number of leading bits shared by all the
+// given IPv4 addresses. Unlike calculatePrefixLength, it doesn't need the
+// minimum and maximum: a bit is shared when it is set in the AND of all the
+// addresses or unset in their OR. It returns 32 for an empty set.
+func commonPrefixLength(ips []uint32) int {
+	if len(ips) == 0 {
+		return 32
+	}
+	and, or := ips[0], ips[0]
+	for _, ip := range ips[1:] {
+		and &= ip
+		or |= ip
+	}
+	return bits.LeadingZeros32(and ^ or)
+}
+
+// spanPrefixLength returns the longest prefix length whose blocks are large
+// enough to hold the addresses from min to max, ignoring alignment. It is
+// longer than calculatePrefixLength(min, max) when the span straddles a block
+// boundary.
+func spanPrefixLength(minUint, maxUint uint32) int {
+	span := uint64(maxUint) - uint64(minUint) + 1
+	return 32 - bits.Len64(span-1)
+}
+
+// alignmentNote explains why the block enclosing the given IPv4 addresses is
+// wider than their span, or returns "" when it isn't.
+func alignmentNote(ips []net.IP, ipnet *net.IPNet) string {
+	var bounds runningBounds
+	for _, ip := range ips {
+		bounds.add(ip)
+	}
+	if !bounds.seen {
+		return ""
+	}
+
+	prefixLen, _ := ipnet.Mask.Size()
+	spanPrefix := spanPrefixLength(bounds.min, bounds.max)
+	if spanPrefix <= prefixLen {
+		return ""
+	}
+	return fmt.Sprintf("Note: %s-%s would fit in a /%d, but no /%d is aligned to contain both ends, so the block is widened to /%d",
+		uint32ToIP(bounds.min), uint32ToIP(bounds.max), spanPrefix, spanPrefix, prefixLen)
+}
+
+// usableHosts returns the number of usable host addresses in an IPv4 block
+// with the given prefix length. The network and broadcast addresses are not
+// usable, except in a /31 where both addresses are hosts (RFC 3021) and in a
+// /32 which is a single host. The count is a uint64 so that a /0 fits even
+// where int is 32 bits.
+func usableHosts(prefixLen int) uint64 {
+	switch prefixLen {
+	case 32:
+		return 1
+	case 31:
+		return 2
+	}
+	return 1<<(32-prefixLen) - 2
+}
+
+// maskForPrefix returns the mask of a prefix of the given length in an address
+// of bits bits (32 for IPv4, 128 for IPv6). Unlike net.CIDRMask, which returns
+// nil, it returns an error when the combination is invalid.
+func maskForPrefix(prefixLen, bits int) (net.IPMask, error) {
+	if bits != 8*net.IPv4len && bits != 8*net.IPv6len {
+		return nil, fmt.Errorf("invalid address size of %d bits", bits)
+	}
+	if prefixLen < 0 || prefixLen > bits {
+		return nil, fmt.Errorf("prefix length %d out of range for a %d-bit address", prefixLen, bits)
+	}
+	return net.CIDRMask(prefixLen, bits), nil
+}
+
+// broadcastIP returns the last address of the given network.
+func broadcastIP(ipnet *net.IPNet) net.IP {
+	ip := make(net.IP, len(ipnet.IP))
+	for i := range ip {
+		ip[i] = ipnet.IP[i] | ^ipnet.Mask[i]
+	}
+	return ip
+}
+
+// debugLog prints debug messages to w with a yellow "debug:" prefix
+func debugLog(w io.Writer, message string) {
 	// Yellow ANSI color code
 	yellow := "\033[33m"
 	reset := "\033[0m"
-	fmt.Fprintf(os.Stderr, "%sdebug:%s %s\n", yellow, reset, message)
+	fmt.Fprintf(w, "%sdebug:%s %s\n", yellow, reset, message)
 }
 
-// ipToUint32 converts an IPv4 address to a uint32.
+// ipToUint32 converts an IPv4 address to a uint32. It returns 0 for nil and
+// IPv6 addresses, the same as for 0.0.0.0, so callers must make sure ip is an
+// IPv4 address first, or use ipv4ToUint32.
 func ipToUint32(ip net.IP) uint32 {
+	u, _ := ipv4ToUint32(ip)
+	return u
+}
+
+// ipv4ToUint32 converts an IPv4 address to a uint32. It returns false when ip
+// is nil or an IPv6 address.
+func ipv4ToUint32(ip net.IP) (uint32, bool) {
 	ip = ip.To4()
 	if ip == nil {
-		return 0
+		return 0, false
 	}
-	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3]), true
 }
 
-// compareIPs compares two IP addresses. Returns -1, 0, or 1.
-func compareIPs(ip1, ip2 net.IP) int {
-	ip1 = ip1.To4()
-	ip2 = ip2.To4()
-	for i := 0; i < 4; i++ {
-		if ip1[i] < ip2[i] {
-			return -1
+// normalizeIP returns ip in its canonical form: 4 bytes for IPv4 and 16 bytes
+// for IPv6. Equal addresses then compare equal byte for byte, whatever their
+// spelling in the input (case, zero padding, IPv4-mapped form).
+func normalizeIP(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip.To16()
+}
+
+// uniqueIPs returns ips without duplicate addresses, keeping the first
+// occurrence of each.
+func uniqueIPs(ips []net.IP) []net.IP {
+	seen := make(map[string]bool, len(ips))
+	var unique []net.IP
+	for _, ip := range ips {
+		key := string(ip.To16())
+		if seen[key] {
+			continue
 		}
-		if ip1[i] > ip2[i] {
-			return 1
+		seen[key] = true
+		unique = append(unique, ip)
+	}
+	return unique
+}
+
+// dumpUint32s logs each IPv4 address along with its uint32 value, to help
+// check conversions when troubleshooting.
+func dumpUint32s(w io.Writer, ips []net.IP) {
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			debugLog(w, fmt.Sprintf("%s = %d", ip, ipToUint32(ip)))
 		}
 	}
-	return 0
+}
+
+// uint32ToIP converts a uint32 to an IPv4 address.
+func uint32ToIP(u uint32) net.IP {
+	return net.IPv4(byte(u>>24), byte(u>>16), byte(u>>8), byte(u)).To4()
+}
+
+// compareIPs compares two IP addresses. Returns -1, 0, or 1. Both families
+// are compared in their 16-byte form, so IPv4 addresses sort before IPv6
+// addresses above ::ffff:255.255.255.255.
+func compareIPs(ip1, ip2 net.IP) int {
+	return bytes.Compare(ip1.To16(), ip2.To16())
 }
@@ -2,11 +2,624 @@ package main
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
 	"net"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
 
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		lookup     func(string) ([]net.IP, error)
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "IPs from stdin",
+			args:       []string{},
+			stdin:      "192.168.1.1\n192.168.1.200\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/24\n",
+		},
+		{
+			name:       "CIDR host from stdin",
+			args:       []string{"-cidr-host"},
+			stdin:      "192.168.1.57/24\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.57/32\n",
+		},
+		{
+			name: "IPs from hostname",
+			args: []string{"-hostname", "example.com"},
+			lookup: func(string) ([]net.IP, error) {
+				return []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}, nil
+			},
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/30\n",
+		},
+		{
+			name: "resolved IPs are logged in order",
+			args: []string{"-debug", "-hostname", "example.com"},
+			lookup: func(string) ([]net.IP, error) {
+				return []net.IP{net.ParseIP("10.0.0.3"), net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}, nil
+			},
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/30\n",
+			wantStderr: "Resolved IPs for example.com: [10.0.0.1 10.0.0.2 10.0.0.3]",
+		},
+		{
+			name:  "hostnames from stdin",
+			args:  []string{"-resolve-stdin", "-debug"},
+			stdin: "a.example.com\n\nb.example.com\nmissing.example.com\n",
+			lookup: func(hostname string) ([]net.IP, error) {
+				switch hostname {
+				case "a.example.com":
+					return []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}, nil
+				case "b.example.com":
+					return []net.IP{net.ParseIP("10.0.0.9")}, nil
+				}
+				return nil, errors.New("no such host")
+			},
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/28\n",
+			wantStderr: "Unresolvable hostname: missing.example.com: no such host",
+		},
+		{
+			name:       "batch",
+			args:       []string{"-batch"},
+			stdin:      "192.168.1.1\n192.168.1.2\n\n10.0.0.1\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/30\n10.0.0.1/32\n",
+		},
+		{
+			name:       "count",
+			args:       []string{"-count"},
+			stdin:      "192.168.1.1\n192.168.1.2\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/30 (usable hosts: 2)\n",
+		},
+		{
+			name:       "count of a single IP",
+			args:       []string{"-count"},
+			stdin:      "192.168.1.1\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.1/32 (usable hosts: 1)\n",
+		},
+		{
+			name:       "range",
+			args:       []string{"-range", "192.168.1.10-192.168.1.20"},
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/27\n",
+		},
+		{
+			name:       "range block count",
+			args:       []string{"-range", "192.168.1.10-192.168.1.20", "-count-blocks"},
+			wantCode:   ExitOK,
+			wantStdout: "4\n",
+		},
+		{
+			name:       "count-blocks without range",
+			args:       []string{"-count-blocks"},
+			wantCode:   ExitBadArgs,
+			wantStderr: "-count-blocks requires -range",
+		},
+		{
+			name:       "normalize",
+			args:       []string{"-normalize"},
+			stdin:      "192.168.1.5/24\n10.0.0.0/8\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/24\n10.0.0.0/8\n",
+			wantStderr: "Normalized 1 of 2 CIDRs.",
+		},
+		{
+			name:       "base",
+			args:       []string{"-base", "192.168.0.0/16"},
+			stdin:      "192.168.1.1\n10.0.0.1\n192.168.1.20\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/27\n",
+		},
+		{
+			name: "base with hostname",
+			args: []string{"-hostname", "example.com", "-base", "10.0.0.0/24"},
+			lookup: func(string) ([]net.IP, error) {
+				return []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.9.0.1"), net.ParseIP("10.0.0.2")}, nil
+			},
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/30\n",
+		},
+		{
+			name:       "invalid base",
+			args:       []string{"-base", "nope"},
+			wantCode:   ExitBadArgs,
+			wantStderr: "invalid value",
+		},
+		{
+			name:       "unspecified address allowed",
+			stdin:      "0.0.0.0\n192.168.1.1\n",
+			wantCode:   ExitOK,
+			wantStdout: "0.0.0.0/0\n",
+		},
+		{
+			name:       "reject unspecified address",
+			args:       []string{"-reject-unspecified", "-debug"},
+			stdin:      "0.0.0.0\n192.168.1.1\n192.168.1.2\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/30\n",
+			wantStderr: "Unspecified address: 0.0.0.0",
+		},
+		{
+			name:       "limit shared by the batch groups",
+			args:       []string{"-batch", "-limit", "3"},
+			stdin:      "10.0.0.1\n10.0.0.2\n\n10.0.0.9\n10.0.0.10\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/30\n10.0.0.9/32\n",
+			wantStderr: "Warning: kept the first 3 IPs and dropped 1 more because of -limit",
+		},
+		{
+			name:       "limit with pcap",
+			args:       []string{"-limit", "3", "-pcap", "capture.pcap"},
+			wantCode:   ExitBadArgs,
+			wantStderr: "-limit cannot be combined with -hostname, -pcap or -range",
+		},
+		{
+			name:       "invalid CIDR reported with its line number",
+			args:       []string{"-debug"},
+			stdin:      "10.0.0.1\n10.0.0.2\n\n10.0.0.0/33\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/30\n",
+			wantStderr: "line 4: Invalid CIDR: 10.0.0.0/33: prefix /33 out of range for IPv4",
+		},
+		{
+			name:       "invalid CIDR in a batch reported with its line number",
+			args:       []string{"-debug", "-batch"},
+			stdin:      "10.0.0.1\n\n10.0.0.2\n10.0.0.0/33\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.1/32\n10.0.0.2/32\n",
+			wantStderr: "line 4: Invalid CIDR: 10.0.0.0/33: prefix /33 out of range for IPv4",
+		},
+		{
+			name:       "reject unspecified IPv6 address",
+			args:       []string{"-reject-unspecified"},
+			stdin:      "::\n",
+			wantCode:   ExitNoInput,
+			wantStderr: "No valid IPs provided.",
+		},
+		{
+			name:       "merge adjacent ranges",
+			args:       []string{"-merge-adjacent-ranges"},
+			stdin:      "10.0.0.10-10.0.0.20\n10.0.0.0-10.0.0.9\n\n10.0.1.0-10.0.1.255\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0-10.0.0.20\n10.0.1.0-10.0.1.255\n",
+		},
+		{
+			name:       "context",
+			args:       []string{"-context"},
+			stdin:      "192.168.1.1\n192.168.1.200\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/24\nparent: 192.168.0.0/23\nchild: 192.168.1.0/25\nchild: 192.168.1.128/25\n",
+		},
+		{
+			name:       "CSV input",
+			args:       []string{"-csv-input", "-csv-column", "1", "-csv-header"},
+			stdin:      "host,ip\nweb-1,192.168.1.10\nweb-2,192.168.1.20\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/27\n",
+		},
+		{
+			name:       "dump uint32 values",
+			args:       []string{"-debug", "-dump-uint"},
+			stdin:      "192.168.1.1\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.1/32\n",
+			wantStderr: "192.168.1.1 = 3232235777",
+		},
+		{
+			name:       "floor prefix splits a /8 into /16 groups",
+			args:       []string{"-floor-prefix", "16"},
+			stdin:      "10.0.0.1\n10.0.0.2\n10.255.0.1\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/30\n10.255.0.1/32\n",
+		},
+		{
+			name:       "floor prefix out of range",
+			args:       []string{"-floor-prefix", "33"},
+			wantCode:   ExitBadArgs,
+			wantStderr: "-floor-prefix must be between 0 and 32",
+		},
+		{
+			name:       "offset from base",
+			args:       []string{"-offset-from", "192.168.1.0/24", "-batch"},
+			stdin:      "192.168.1.64/26\n\n192.168.1.192/26\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.64/26 (subnet 1 of /26 within 192.168.1.0/24)\n192.168.1.192/26 (subnet 3 of /26 within 192.168.1.0/24)\n",
+		},
+		{
+			name:       "offset from a base not holding the block",
+			args:       []string{"-offset-from", "10.0.0.0/24"},
+			stdin:      "192.168.1.1\n",
+			wantCode:   ExitFailure,
+			wantStderr: "192.168.1.1/32 is not within 10.0.0.0/24",
+		},
+		{
+			name:       "summary",
+			args:       []string{"-summary"},
+			stdin:      "192.168.1.1\n192.168.1.2\n192.168.1.2\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/30\n",
+			wantStderr: "aggregated 3 IPs (2 unique) into 192.168.1.0/30 covering 4 addresses (50% utilization)",
+		},
+		{
+			name:       "VPC check passes",
+			args:       []string{"-vpc-check"},
+			stdin:      "10.0.0.1\n10.0.15.1\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/20\n",
+		},
+		{
+			name:       "VPC check fails",
+			args:       []string{"-vpc-check"},
+			stdin:      "10.0.0.1\n10.200.0.1\n",
+			wantCode:   ExitFailure,
+			wantStderr: "10.0.0.0/8 is too broad for an AWS VPC",
+		},
+		{
+			name:       "combine two CIDRs",
+			args:       []string{"-combine", "10.0.1.0/24", "10.0.2.0/24"},
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/22\n",
+		},
+		{
+			name:       "combine needs two CIDRs",
+			args:       []string{"-combine", "10.0.1.0/24"},
+			wantCode:   ExitBadArgs,
+			wantStderr: "-combine requires exactly two CIDR arguments",
+		},
+		{
+			name:       "JSON output",
+			args:       []string{"-json"},
+			stdin:      "10.0.0.1\n10.0.0.2\n",
+			wantCode:   ExitOK,
+			wantStdout: `{"cidr":"10.0.0.0/30","prefix":30,"network":"10.0.0.0","broadcast":"10.0.0.3","hosts":2}` + "\n",
+		},
+		{
+			name:       "duplicates are counted once by default",
+			args:       []string{"-debug"},
+			stdin:      "10.0.0.1\n10.0.0.1\n10.0.0.1\n10.0.0.2\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/30\n",
+			wantStderr: "2 unique IPs",
+		},
+		{
+			name:       "no dedup counts every occurrence",
+			args:       []string{"-debug", "-no-dedup"},
+			stdin:      "10.0.0.1\n10.0.0.1\n10.0.0.1\n10.0.0.2\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/30\n",
+			wantStderr: "4 IPs, duplicates included",
+		},
+		{
+			name:       "no dedup keeps the summary accurate",
+			args:       []string{"-summary", "-no-dedup"},
+			stdin:      "10.0.0.1\n10.0.0.1\n10.0.0.1\n10.0.0.2\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/30\n",
+			wantStderr: "aggregated 4 IPs (2 unique)",
+		},
+		{
+			name:       "self-check",
+			args:       []string{"-self-check", "-minimal"},
+			stdin:      "10.0.0.9\n10.0.0.1\n10.0.3.200\n10.0.0.2\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.1/32\n10.0.0.2/32\n10.0.0.9/32\n10.0.3.200/32\n",
+		},
+		{
+			name:       "grand total of batch groups",
+			args:       []string{"-batch", "-grand-total"},
+			stdin:      "10.0.0.1\n10.0.0.2\n\n10.0.5.1\n\n10.0.9.7\n10.0.9.8\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/30\n10.0.5.1/32\n10.0.9.0/28\n10.0.0.0/20\n",
+		},
+		{
+			name:       "grand total requires batch",
+			args:       []string{"-grand-total"},
+			wantCode:   ExitBadArgs,
+			wantStderr: "-grand-total requires -batch",
+		},
+		{
+			name:       "contiguous IPs",
+			args:       []string{"-require-contiguous", "-allow-missing-edges"},
+			stdin:      "10.0.0.1\n10.0.0.2\n10.0.0.3\n10.0.0.4\n10.0.0.5\n10.0.0.6\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/29\n",
+		},
+		{
+			name:       "gap in the IPs",
+			args:       []string{"-require-contiguous"},
+			stdin:      "10.0.0.0\n10.0.0.1\n10.0.0.3\n",
+			wantCode:   ExitFailure,
+			wantStderr: "the IPs are not contiguous in 10.0.0.0/30, the first gap is 10.0.0.2-10.0.0.2",
+		},
+		{
+			name:       "gaps within max-gap",
+			args:       []string{"-max-gap", "10"},
+			stdin:      "10.0.0.1\n10.0.0.11\n10.0.0.5\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/28\n",
+		},
+		{
+			name:       "gap over max-gap",
+			args:       []string{"-max-gap", "10"},
+			stdin:      "10.0.0.1\n10.0.0.5\n10.0.0.200\n",
+			wantCode:   ExitFailure,
+			wantStderr: "10.0.0.5 and 10.0.0.200 are 195 addresses apart, more than -max-gap 10",
+		},
+		{
+			name:       "spread",
+			args:       []string{"-spread"},
+			stdin:      "10.0.0.1\n10.0.0.2\n10.0.0.200\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/24\n",
+			wantStderr: "spread: 2 of 16 /28 sub-blocks touched",
+		},
+		{
+			name:       "trim outliers",
+			args:       []string{"-trim-percentile", "10"},
+			stdin:      "10.0.0.1\n10.0.0.2\n10.0.0.3\n10.0.0.4\n10.0.0.5\n10.0.0.6\n10.0.0.7\n10.0.0.8\n10.0.0.200\n203.0.113.9\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/24\n",
+			wantStderr: "Trimmed 2 outliers, narrowing 0.0.0.0/0 to 10.0.0.0/24.",
+		},
+		{
+			name:       "minimal blocks of a range",
+			args:       []string{"-range", "10.0.0.1-10.0.0.10", "-minimal"},
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.1/32\n10.0.0.2/31\n10.0.0.4/30\n10.0.0.8/31\n10.0.0.10/32\n",
+		},
+		{
+			name:       "compare to a reference",
+			args:       []string{"-compare-to", "10.0.0.0/16"},
+			stdin:      "10.0.0.1\n10.0.0.2\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/30\n",
+			wantStderr: "10.0.0.0/30 is narrower than 10.0.0.0/16 by 65532 addresses",
+		},
+		{
+			name:       "split within a prefix window",
+			args:       []string{"-split", "25", "-min-prefix", "24", "-max-prefix", "26"},
+			stdin:      "10.0.0.1\n10.0.0.200\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.0/25\n10.0.0.128/25\n",
+		},
+		{
+			name:       "split outside of the prefix window",
+			args:       []string{"-split", "28", "-max-prefix", "26"},
+			stdin:      "10.0.0.1\n10.0.0.200\n",
+			wantCode:   ExitFailure,
+			wantStderr: "10.0.0.0/28 is longer than /26",
+		},
+		{
+			name:       "prefix only",
+			args:       []string{"-prefix-only"},
+			stdin:      "192.168.1.1\n192.168.1.200\n",
+			wantCode:   ExitOK,
+			wantStdout: "24\n",
+		},
+		{
+			name:       "prefix only cannot be combined with JSON",
+			args:       []string{"-prefix-only", "-json"},
+			wantCode:   ExitBadArgs,
+			wantStderr: "-prefix-only cannot be combined with -json",
+		},
+		{
+			name:       "align IPv6 to /48",
+			args:       []string{"-align", "48"},
+			stdin:      "2001:db8:0:1::/64\n2001:db8:0:f0::/64\n",
+			wantCode:   ExitOK,
+			wantStdout: "2001:db8::/48\n",
+		},
+		{
+			name:       "count usable inputs",
+			args:       []string{"-count-usable-in-inputs"},
+			stdin:      "192.168.1.0\n192.168.1.7\n192.168.1.255\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/24\n",
+			wantStderr: "1 of 3 input IPs are usable hosts in 192.168.1.0/24",
+		},
+		{
+			name:       "debug output goes to stderr",
+			args:       []string{"-debug"},
+			stdin:      "invalid\n10.0.0.1\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.1/32\n",
+			wantStderr: "Invalid IP: invalid",
+		},
+		{
+			name:       "no input",
+			args:       []string{},
+			stdin:      "",
+			wantCode:   ExitNoInput,
+			wantStderr: "No valid IPs provided.",
+		},
+		{
+			name:       "only invalid input",
+			args:       []string{},
+			stdin:      "invalid\n",
+			wantCode:   ExitNoInput,
+			wantStderr: "No valid IPs provided.",
+		},
+		{
+			name:     "no input with -allow-empty",
+			args:     []string{"-allow-empty"},
+			stdin:    "",
+			wantCode: ExitOK,
+		},
+		{
+			name:     "only invalid input with -allow-empty",
+			args:     []string{"-allow-empty"},
+			stdin:    "invalid\n",
+			wantCode: ExitOK,
+		},
+		{
+			name: "resolve failure",
+			args: []string{"-hostname", "example.invalid"},
+			lookup: func(string) ([]net.IP, error) {
+				return nil, errors.New("no such host")
+			},
+			wantCode:   ExitResolveFail,
+			wantStderr: "Error resolving hostname example.invalid: no such host",
+		},
+		{
+			name:       "bad arguments",
+			args:       []string{"-no-such-flag"},
+			wantCode:   ExitBadArgs,
+			wantStderr: "flag provided but not defined: -no-such-flag",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.lookup != nil {
+				orig := lookupIP
+				lookupIP = tt.lookup
+				defer func() { lookupIP = orig }()
+			}
+
+			var stdout, stderr bytes.Buffer
+			got := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if got != tt.wantCode {
+				t.Errorf("run() = %d, want %d (stderr: %q)", got, tt.wantCode, stderr.String())
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRunInclude(t *testing.T) {
+	dir := t.TempDir()
+	pinned := filepath.Join(dir, "pinned.txt")
+	if err := os.WriteFile(pinned, []byte("192.168.1.30\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	more := filepath.Join(dir, "more.txt")
+	if err := os.WriteFile(more, []byte("192.168.1.40\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "merged with stdin",
+			args:       []string{"-include", pinned},
+			stdin:      "192.168.1.1\n192.168.1.2\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/27\n",
+		},
+		{
+			name:       "several files",
+			args:       []string{"-include", pinned, "-include", more},
+			stdin:      "192.168.1.1\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/26\n",
+		},
+		{
+			name:       "added to every batch",
+			args:       []string{"-batch", "-include", pinned},
+			stdin:      "192.168.1.20\n\n192.168.1.31\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.16/28\n192.168.1.30/31\n",
+		},
+		{
+			name:       "counted by -limit",
+			args:       []string{"-limit", "2", "-include", pinned},
+			stdin:      "192.168.1.1\n192.168.1.2\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/30\n",
+			wantStderr: "Warning: kept the first 2 IPs and dropped 1 more because of -limit\n",
+		},
+		{
+			name:     "missing file",
+			args:     []string{"-include", filepath.Join(dir, "missing.txt")},
+			stdin:    "192.168.1.1\n",
+			wantCode: ExitFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Errorf("run() = %d, want %d (stderr: %q)", code, tt.wantCode, stderr.String())
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if tt.wantStderr != "" && stderr.String() != tt.wantStderr {
+				t.Errorf("run() stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRunQuietErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		wantCode int
+	}{
+		{name: "no input", args: []string{"-quiet-errors"}, stdin: "", wantCode: ExitNoInput},
+		{name: "invalid flag combination", args: []string{"-quiet-errors", "-labels", "x"}, stdin: "", wantCode: ExitBadArgs},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Errorf("run() = %d, want %d", code, tt.wantCode)
+			}
+			if stderr.Len() != 0 {
+				t.Errorf("run() stderr = %q, want nothing", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunQuietErrorsKeepsDebugOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-quiet-errors", "-debug"}, strings.NewReader("invalid\n"), &stdout, &stderr)
+	if code != ExitNoInput {
+		t.Errorf("run() = %d, want %d", code, ExitNoInput)
+	}
+	if !strings.Contains(stderr.String(), "Invalid IP: invalid") {
+		t.Errorf("run() stderr = %q, want the debug output", stderr.String())
+	}
+	if strings.Contains(stderr.String(), "No valid IPs provided.") {
+		t.Errorf("run() stderr = %q, want no error message", stderr.String())
+	}
+}
+
 func TestCalculateCIDR(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -39,11 +652,37 @@ func TestCalculateCIDR(t *testing.T) {
 			ips:  []string{"192.168.1.100", "192.168.1.1", "192.168.1.50"},
 			want: "192.168.1.0/25",
 		},
+		{
+			name: "pair aligned on a /31",
+			ips:  []string{"192.168.1.0", "192.168.1.1"},
+			want: "192.168.1.0/31",
+		},
+		{
+			name: "pair straddling a /31 boundary",
+			ips:  []string{"192.168.1.1", "192.168.1.2"},
+			want: "192.168.1.0/30",
+		},
+		{
+			name: "next pair aligned on a /31",
+			ips:  []string{"192.168.1.2", "192.168.1.3"},
+			want: "192.168.1.2/31",
+		},
 		{
 			name:    "empty IP list",
 			ips:     []string{},
 			wantErr: true,
 		},
+		{
+			name: "0.0.0.0 is an address",
+			ips:  []string{"0.0.0.0", "10.0.0.1"},
+			want: "0.0.0.0/4",
+		},
+		{
+			// net.ParseIP("") returns nil, which must not be taken for 0.0.0.0.
+			name:    "nil IP",
+			ips:     []string{"", "10.0.0.1"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -70,6 +709,7 @@ func TestParseIPsFromReader(t *testing.T) {
 		name      string
 		input     string
 		debug     bool
+		cidrHost  bool
 		wantCount int
 		wantIPs   []string
 	}{
@@ -101,12 +741,32 @@ func TestParseIPsFromReader(t *testing.T) {
 			wantCount: 0,
 			wantIPs:   []string{},
 		},
+		{
+			name:      "CIDR contributes its whole block",
+			input:     "192.168.1.57/24",
+			wantCount: 2,
+			wantIPs:   []string{"192.168.1.0", "192.168.1.255"},
+		},
+		{
+			name:      "CIDR contributes its host with cidr-host",
+			input:     "192.168.1.57/24\n10.0.0.1",
+			cidrHost:  true,
+			wantCount: 2,
+			wantIPs:   []string{"192.168.1.57", "10.0.0.1"},
+		},
+		{
+			name:      "invalid CIDR",
+			input:     "192.168.1.57/33",
+			cidrHost:  true,
+			wantCount: 0,
+			wantIPs:   []string{},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			reader := strings.NewReader(tt.input)
-			ips, err := parseIPsFromReader(reader, tt.debug)
+			ips, err := parseIPsFromReader(reader, parseOptions{debug: tt.debug, cidrHost: tt.cidrHost})
 			if err != nil {
 				t.Errorf("parseIPsFromReader() error = %v", err)
 				return
@@ -127,6 +787,253 @@ func TestParseIPsFromReader(t *testing.T) {
 	}
 }
 
+func TestCIDRHostAggregation(t *testing.T) {
+	tests := []struct {
+		name     string
+		cidrHost bool
+		want     string
+	}{
+		{
+			name:     "host address is used with cidr-host",
+			cidrHost: true,
+			want:     "192.168.1.57/32",
+		},
+		{
+			name:     "whole block is used without cidr-host",
+			cidrHost: false,
+			want:     "192.168.1.0/24",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ips, err := parseIPsFromReader(strings.NewReader("192.168.1.57/24"), parseOptions{cidrHost: tt.cidrHost})
+			if err != nil {
+				t.Fatalf("parseIPsFromReader() error = %v", err)
+			}
+			got, err := calculateCIDR(ips)
+			if err != nil {
+				t.Fatalf("calculateCIDR() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("calculateCIDR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGroupsFromReader(t *testing.T) {
+	input := "192.168.1.1\n192.168.1.2\n\n\n10.0.0.1\n\ninvalid\n\n172.16.0.1"
+	groups, err := parseGroupsFromReader(strings.NewReader(input), parseOptions{})
+	if err != nil {
+		t.Fatalf("parseGroupsFromReader() error = %v", err)
+	}
+
+	want := [][]string{
+		{"192.168.1.1", "192.168.1.2"},
+		{"10.0.0.1"},
+		{},
+		{"172.16.0.1"},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("parseGroupsFromReader() got %d groups, want %d", len(groups), len(want))
+	}
+	for i := range want {
+		if len(groups[i]) != len(want[i]) {
+			t.Errorf("group %d = %v, want %v", i, groups[i], want[i])
+			continue
+		}
+		for j := range want[i] {
+			if groups[i][j].String() != want[i][j] {
+				t.Errorf("group %d IP[%d] = %v, want %v", i, j, groups[i][j], want[i][j])
+			}
+		}
+	}
+}
+
+func TestParseIPsFromReaderBase(t *testing.T) {
+	_, base, _ := net.ParseCIDR("192.168.0.0/16")
+	input := "192.168.1.1\n10.0.0.1\n192.168.200.7\n192.168.1.0/24\n192.0.0.0/8\n172.16.0.1\n"
+
+	var stderr bytes.Buffer
+	ips, err := parseIPsFromReader(strings.NewReader(input), parseOptions{debug: true, stderr: &stderr, base: base})
+	if err != nil {
+		t.Fatalf("parseIPsFromReader() error = %v", err)
+	}
+
+	want := []string{"192.168.1.1", "192.168.200.7", "192.168.1.0", "192.168.1.255"}
+	if len(ips) != len(want) {
+		t.Fatalf("parseIPsFromReader() = %v, want %v", ips, want)
+	}
+	for i := range want {
+		if ips[i].String() != want[i] {
+			t.Errorf("parseIPsFromReader() IP[%d] = %v, want %v", i, ips[i], want[i])
+		}
+	}
+	for _, line := range []string{"10.0.0.1", "192.0.0.0/8", "172.16.0.1"} {
+		if !strings.Contains(stderr.String(), "Outside base network 192.168.0.0/16: "+line) {
+			t.Errorf("stderr = %q, want a note about %s", stderr.String(), line)
+		}
+	}
+}
+
+func TestParseIPsFromReaderZone(t *testing.T) {
+	input := "fe80::1%eth0\nfe80::2%en0/64\nfe80::3%\n192.168.1.1%eth0\nfe80::4\n"
+
+	tests := []struct {
+		name      string
+		allowZone bool
+		want      []string
+	}{
+		{name: "zones rejected by default", want: []string{"fe80::4"}},
+		{name: "zones allowed", allowZone: true, want: []string{"fe80::1", "fe80::", "fe80::ffff:ffff:ffff:ffff", "fe80::4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ips, err := parseIPsFromReader(strings.NewReader(input), parseOptions{allowZone: tt.allowZone})
+			if err != nil {
+				t.Fatalf("parseIPsFromReader() error = %v", err)
+			}
+			var got []string
+			for _, ip := range ips {
+				got = append(got, ip.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseIPsFromReader() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIPsFromReaderLimit(t *testing.T) {
+	input := "192.168.1.1\ninvalid\n192.168.1.2\n192.168.1.3\n10.0.0.0/24\n192.168.1.4\n"
+
+	limit := &inputLimit{max: 2}
+	ips, err := parseIPsFromReader(strings.NewReader(input), parseOptions{limit: limit})
+	if err != nil {
+		t.Fatalf("parseIPsFromReader() error = %v", err)
+	}
+
+	want := []string{"192.168.1.1", "192.168.1.2"}
+	if len(ips) != len(want) {
+		t.Fatalf("parseIPsFromReader() = %v, want %v", ips, want)
+	}
+	for i := range want {
+		if ips[i].String() != want[i] {
+			t.Errorf("parseIPsFromReader() IP[%d] = %v, want %v", i, ips[i], want[i])
+		}
+	}
+	var stderr bytes.Buffer
+	limit.warn(&stderr)
+	if wantStderr := "Warning: kept the first 2 IPs and dropped 4 more because of -limit\n"; stderr.String() != wantStderr {
+		t.Errorf("warn() = %q, want %q", stderr.String(), wantStderr)
+	}
+}
+
+func TestParseIPsFromReaderLimitStopsAtCIDR(t *testing.T) {
+	input := "10.0.0.1\n10.0.0.0/31\n10.0.0.9\n"
+
+	limit := &inputLimit{max: 2}
+	ips, err := parseIPsFromReader(strings.NewReader(input), parseOptions{limit: limit})
+	if err != nil {
+		t.Fatalf("parseIPsFromReader() error = %v", err)
+	}
+
+	if len(ips) != 1 || ips[0].String() != "10.0.0.1" {
+		t.Errorf("parseIPsFromReader() = %v, want [10.0.0.1]", ips)
+	}
+	var stderr bytes.Buffer
+	limit.warn(&stderr)
+	if wantStderr := "Warning: kept the first 1 IPs and dropped 3 more because of -limit\n"; stderr.String() != wantStderr {
+		t.Errorf("warn() = %q, want %q", stderr.String(), wantStderr)
+	}
+}
+
+func TestParseIPsFromReaderAlertPrefix(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		alertPrefix int
+		wantStderr  string
+	}{
+		{
+			name:        "outlier mid-stream",
+			input:       "192.168.1.1\n192.168.1.200\n10.0.0.1\n192.168.1.3\n172.16.0.1\n",
+			alertPrefix: 24,
+			wantStderr:  "Warning: 10.0.0.1 broadens the enclosing block to 0.0.0.0/0, shorter than /24\n",
+		},
+		{
+			name:        "within limit",
+			input:       "192.168.1.1\n192.168.1.200\n",
+			alertPrefix: 24,
+			wantStderr:  "",
+		},
+		{
+			name:        "disabled",
+			input:       "192.168.1.1\n10.0.0.1\n",
+			alertPrefix: 0,
+			wantStderr:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			_, err := parseIPsFromReader(strings.NewReader(tt.input), parseOptions{stderr: &stderr, alertPrefix: tt.alertPrefix})
+			if err != nil {
+				t.Fatalf("parseIPsFromReader() error = %v", err)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("parseIPsFromReader() stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestUsableHosts(t *testing.T) {
+	tests := []struct {
+		prefixLen int
+		want      uint64
+	}{
+		{prefixLen: 0, want: 1<<32 - 2},
+		{prefixLen: 24, want: 254},
+		{prefixLen: 29, want: 6},
+		{prefixLen: 30, want: 2},
+		{prefixLen: 31, want: 2},
+		{prefixLen: 32, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("/%d", tt.prefixLen), func(t *testing.T) {
+			if got := usableHosts(tt.prefixLen); got != tt.want {
+				t.Errorf("usableHosts(%d) = %d, want %d", tt.prefixLen, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniqueIPs(t *testing.T) {
+	ips := []net.IP{
+		net.ParseIP("192.168.1.2"),
+		net.ParseIP("192.168.1.1"),
+		net.ParseIP("192.168.1.2").To4(),
+		net.ParseIP("::ffff:192.168.1.1"),
+		net.ParseIP("192.168.1.3"),
+	}
+
+	got := uniqueIPs(ips)
+	want := []string{"192.168.1.2", "192.168.1.1", "192.168.1.3"}
+	if len(got) != len(want) {
+		t.Fatalf("uniqueIPs() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].String() != want[i] {
+			t.Errorf("uniqueIPs()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
 func TestIPToUint32(t *testing.T) {
 	tests := []struct {
 		name string
@@ -165,6 +1072,79 @@ func TestIPToUint32(t *testing.T) {
 	}
 }
 
+func TestIPv4ToUint32(t *testing.T) {
+	tests := []struct {
+		name   string
+		ip     net.IP
+		want   uint32
+		wantOK bool
+	}{
+		{
+			name:   "0.0.0.0",
+			ip:     net.ParseIP("0.0.0.0"),
+			want:   0,
+			wantOK: true,
+		},
+		{
+			name:   "10.0.0.1",
+			ip:     net.ParseIP("10.0.0.1"),
+			want:   167772161,
+			wantOK: true,
+		},
+		{
+			name:   "nil",
+			ip:     nil,
+			wantOK: false,
+		},
+		{
+			name:   "IPv6",
+			ip:     net.ParseIP("2001:db8::1"),
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ipv4ToUint32(tt.ip)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ipv4ToUint32() = (%v, %v), want (%v, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMaskForPrefix(t *testing.T) {
+	tests := []struct {
+		name      string
+		prefixLen int
+		bits      int
+		want      string
+		wantErr   bool
+	}{
+		{name: "IPv4 /24", prefixLen: 24, bits: 32, want: "ffffff00"},
+		{name: "IPv4 /0", prefixLen: 0, bits: 32, want: "00000000"},
+		{name: "IPv4 /32", prefixLen: 32, bits: 32, want: "ffffffff"},
+		{name: "IPv6 /64", prefixLen: 64, bits: 128, want: "ffffffffffffffff0000000000000000"},
+		{name: "IPv6 /128", prefixLen: 128, bits: 128, want: "ffffffffffffffffffffffffffffffff"},
+		{name: "IPv4 prefix too long", prefixLen: 33, bits: 32, wantErr: true},
+		{name: "negative prefix", prefixLen: -1, bits: 32, wantErr: true},
+		{name: "IPv6 prefix too long", prefixLen: 129, bits: 128, wantErr: true},
+		{name: "invalid size", prefixLen: 8, bits: 64, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := maskForPrefix(tt.prefixLen, tt.bits)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("maskForPrefix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("maskForPrefix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestCompareIPs(t *testing.T) {
 	tests := []struct {
 		name string
@@ -196,6 +1176,30 @@ func TestCompareIPs(t *testing.T) {
 			ip2:  "192.169.1.1",
 			want: -1,
 		},
+		{
+			name: "equal IPv6",
+			ip1:  "2001:db8::1",
+			ip2:  "2001:db8::1",
+			want: 0,
+		},
+		{
+			name: "IPv6 ip1 < ip2",
+			ip1:  "2001:db8::1",
+			ip2:  "2001:db8::1:0",
+			want: -1,
+		},
+		{
+			name: "IPv6 ip1 > ip2",
+			ip1:  "2001:db9::",
+			ip2:  "2001:db8:ffff::",
+			want: 1,
+		},
+		{
+			name: "IPv4 sorts before IPv6",
+			ip1:  "255.255.255.255",
+			ip2:  "2001:db8::1",
+			want: -1,
+		},
 	}
 
 	for _, tt := range tests {
@@ -209,6 +1213,79 @@ func TestCompareIPs(t *testing.T) {
 	}
 }
 
+func TestSortByIPMixedFamilies(t *testing.T) {
+	var ips []net.IP
+	for _, s := range []string{"2001:db8::1", "192.168.1.1", "::1", "10.0.0.1", "2001:db8::", "fe80::1", "::ffff:10.0.0.2"} {
+		ips = append(ips, net.ParseIP(s))
+	}
+
+	sortByIP(ips, func(ip net.IP) net.IP { return ip })
+
+	// IPv4 addresses are IPv4-mapped in their 16-byte form, which places
+	// them between ::1 and 2001:db8::.
+	want := []string{"::1", "10.0.0.1", "10.0.0.2", "192.168.1.1", "2001:db8::", "2001:db8::1", "fe80::1"}
+	var got []string
+	for _, ip := range ips {
+		got = append(got, ip.String())
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("sortByIP() = %v, want %v", got, want)
+	}
+}
+
+func TestSortByIPIsStable(t *testing.T) {
+	type line struct {
+		ip  net.IP
+		num int
+	}
+	var lines []line
+	for i, s := range []string{"192.168.1.2", "192.168.1.1", "192.168.1.2", "10.0.0.1", "192.168.1.1", "192.168.1.2"} {
+		lines = append(lines, line{ip: net.ParseIP(s), num: i + 1})
+	}
+
+	sortByIP(lines, func(l line) net.IP { return l.ip })
+
+	want := []line{
+		{net.ParseIP("10.0.0.1"), 4},
+		{net.ParseIP("192.168.1.1"), 2},
+		{net.ParseIP("192.168.1.1"), 5},
+		{net.ParseIP("192.168.1.2"), 1},
+		{net.ParseIP("192.168.1.2"), 3},
+		{net.ParseIP("192.168.1.2"), 6},
+	}
+	for i := range want {
+		if !lines[i].ip.Equal(want[i].ip) || lines[i].num != want[i].num {
+			t.Errorf("sortByIP()[%d] = %v (line %d), want %v (line %d)", i, lines[i].ip, lines[i].num, want[i].ip, want[i].num)
+		}
+	}
+}
+
+func TestCommonPrefixBits(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want int
+	}{
+		{name: "same IPv4", a: "192.168.1.1", b: "192.168.1.1", want: 32},
+		{name: "IPv4 /24", a: "192.168.1.1", b: "192.168.1.254", want: 24},
+		{name: "IPv4 /18", a: "192.168.1.1", b: "192.168.50.1", want: 18},
+		{name: "IPv4 first bit", a: "0.0.0.0", b: "128.0.0.0", want: 0},
+		{name: "same IPv6", a: "2001:db8::1", b: "2001:db8::1", want: 128},
+		{name: "IPv6 /32", a: "2001:db8::", b: "2001:db8:ffff::", want: 32},
+		{name: "IPv6 /125", a: "2001:db8::1", b: "2001:db8::6", want: 125},
+		{name: "IPv4 and IPv6", a: "192.168.1.1", b: "2001:db8::1", want: -1},
+		{name: "invalid", a: "", b: "192.168.1.1", want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commonPrefixBits(net.ParseIP(tt.a), net.ParseIP(tt.b)); got != tt.want {
+				t.Errorf("commonPrefixBits() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestCalculatePrefixLength(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -253,6 +1330,180 @@ func TestCalculatePrefixLength(t *testing.T) {
 	}
 }
 
+func TestCommonPrefixLength(t *testing.T) {
+	tests := []struct {
+		name string
+		ips  []string
+		want int
+	}{
+		{name: "empty", ips: nil, want: 32},
+		{name: "single IP", ips: []string{"192.168.1.1"}, want: 32},
+		{name: "unsorted", ips: []string{"192.168.1.100", "192.168.1.1", "192.168.1.50"}, want: 25},
+		{name: "across /16", ips: []string{"192.168.1.1", "192.168.50.1"}, want: 18},
+		{name: "nothing shared", ips: []string{"0.0.0.0", "128.0.0.0"}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ips []uint32
+			for _, s := range tt.ips {
+				ips = append(ips, ipToUint32(net.ParseIP(s)))
+			}
+			if got := commonPrefixLength(ips); got != tt.want {
+				t.Errorf("commonPrefixLength() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommonPrefixLengthMatchesMinMax(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		// Share a random number of leading bits so that all prefix lengths
+		// get exercised, not just the short ones of fully random sets.
+		shared := r.Intn(33)
+		base := r.Uint32()
+		ips := make([]uint32, 1+r.Intn(20))
+		minUint, maxUint := uint32(0xffffffff), uint32(0)
+		for j := range ips {
+			ips[j] = base
+			if shared < 32 {
+				ips[j] = base&^(0xffffffff>>shared) | r.Uint32()>>shared
+			}
+			minUint = min(minUint, ips[j])
+			maxUint = max(maxUint, ips[j])
+		}
+
+		got := commonPrefixLength(ips)
+		want := calculatePrefixLength(minUint, maxUint)
+		if got != want {
+			t.Fatalf("commonPrefixLength(%v) = %d, calculatePrefixLength() = %d", ips, got, want)
+		}
+	}
+}
+
+func TestSpanPrefixLength(t *testing.T) {
+	tests := []struct {
+		name  string
+		minIP string
+		maxIP string
+		want  int
+	}{
+		{name: "(.0,.1)", minIP: "192.168.1.0", maxIP: "192.168.1.1", want: 31},
+		{name: "(.1,.2)", minIP: "192.168.1.1", maxIP: "192.168.1.2", want: 31},
+		{name: "(.2,.3)", minIP: "192.168.1.2", maxIP: "192.168.1.3", want: 31},
+		{name: "single IP", minIP: "192.168.1.1", maxIP: "192.168.1.1", want: 32},
+		{name: "three IPs", minIP: "192.168.1.1", maxIP: "192.168.1.3", want: 30},
+		{name: "whole space", minIP: "0.0.0.0", maxIP: "255.255.255.255", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minUint := ipToUint32(net.ParseIP(tt.minIP))
+			maxUint := ipToUint32(net.ParseIP(tt.maxIP))
+			if got := spanPrefixLength(minUint, maxUint); got != tt.want {
+				t.Errorf("spanPrefixLength() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlignmentNote(t *testing.T) {
+	tests := []struct {
+		name string
+		ips  []string
+		want string
+	}{
+		{
+			name: "(.0,.1) is aligned",
+			ips:  []string{"192.168.1.0", "192.168.1.1"},
+			want: "",
+		},
+		{
+			name: "(.1,.2) straddles a /31",
+			ips:  []string{"192.168.1.1", "192.168.1.2"},
+			want: "Note: 192.168.1.1-192.168.1.2 would fit in a /31, but no /31 is aligned to contain both ends, so the block is widened to /30",
+		},
+		{
+			name: "(.2,.3) is aligned",
+			ips:  []string{"192.168.1.2", "192.168.1.3"},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ips []net.IP
+			for _, s := range tt.ips {
+				ips = append(ips, net.ParseIP(s))
+			}
+			ipnet, err := calculateIPNet(ips)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := alignmentNote(ips, ipnet); got != tt.want {
+				t.Errorf("alignmentNote() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateCIDRIdentical(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		n    int
+		want string
+	}{
+		{name: "single IPv4", ip: "192.168.1.1", n: 1, want: "192.168.1.1/32"},
+		{name: "repeated IPv4", ip: "192.168.1.1", n: 1000000, want: "192.168.1.1/32"},
+		{name: "repeated IPv6", ip: "2001:db8::1", n: 1000, want: "2001:db8::1/128"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ips := make([]net.IP, tt.n)
+			for i := range ips {
+				ips[i] = net.ParseIP(tt.ip)
+			}
+			got, err := calculateCIDR(ips)
+			if err != nil {
+				t.Fatalf("calculateCIDR() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("calculateCIDR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSingleAddress(t *testing.T) {
+	tests := []struct {
+		name string
+		ips  []string
+		want net.IP
+	}{
+		{name: "single IPv4", ips: []string{"192.168.1.1"}, want: net.IP{192, 168, 1, 1}},
+		{name: "repeated IPv4", ips: []string{"192.168.1.1", "192.168.1.1", "192.168.1.1"}, want: net.IP{192, 168, 1, 1}},
+		{name: "repeated IPv6", ips: []string{"2001:db8::1", "2001:db8::1"}, want: net.ParseIP("2001:db8::1")},
+		{name: "differs last", ips: []string{"192.168.1.1", "192.168.1.1", "192.168.1.2"}, want: nil},
+		{name: "IPv4 and IPv6", ips: []string{"192.168.1.1", "2001:db8::1"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ips := make([]net.IP, len(tt.ips))
+			for i, s := range tt.ips {
+				ips[i] = net.ParseIP(s)
+			}
+			got := singleAddress(ips)
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("singleAddress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func BenchmarkCalculateCIDR(b *testing.B) {
 	ips := []net.IP{
 		net.ParseIP("192.168.1.1"),
@@ -267,12 +1518,24 @@ func BenchmarkCalculateCIDR(b *testing.B) {
 	}
 }
 
+func BenchmarkCalculateCIDRIdentical(b *testing.B) {
+	ips := make([]net.IP, 1000000)
+	for i := range ips {
+		ips[i] = net.ParseIP("192.168.1.1")
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = calculateCIDR(ips)
+	}
+}
+
 func BenchmarkParseIPsFromReader(b *testing.B) {
 	input := "192.168.1.1\n192.168.1.2\n192.168.1.3\n192.168.1.4\n192.168.1.5"
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		reader := bytes.NewReader([]byte(input))
-		_, _ = parseIPsFromReader(reader, false)
+		_, _ = parseIPsFromReader(reader, parseOptions{})
 	}
 }
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+)
+
+// mergeCIDRs returns the smallest set of IPv4 blocks that covers exactly the
+// same addresses as nets, sorted by address. Blocks nested in another block are
+// dropped and sibling blocks are joined into their parent.
+func mergeCIDRs(nets []*net.IPNet) []*net.IPNet {
+	sorted := make([]*net.IPNet, len(nets))
+	copy(sorted, nets)
+	sort.Slice(sorted, func(i, j int) bool {
+		if c := compareIPs(sorted[i].IP, sorted[j].IP); c != 0 {
+			return c < 0
+		}
+		// For blocks starting at the same address, keep the largest first so
+		// that the others are seen as nested in it.
+		iOnes, _ := sorted[i].Mask.Size()
+		jOnes, _ := sorted[j].Mask.Size()
+		return iOnes < jOnes
+	})
+
+	var merged []*net.IPNet
+	for _, n := range sorted {
+		if len(merged) > 0 && merged[len(merged)-1].Contains(n.IP) {
+			continue
+		}
+		merged = append(merged, n)
+
+		// Joining two siblings may produce a block that is itself the
+		// sibling of the previous one, so keep folding.
+		for len(merged) >= 2 {
+			parent, ok := siblingParent(merged[len(merged)-2], merged[len(merged)-1])
+			if !ok {
+				break
+			}
+			merged = append(merged[:len(merged)-2], parent)
+		}
+	}
+	return merged
+}
+
+// siblingParent returns the block made of a and b when a is the lower half and
+// b the upper half of the same parent block.
+func siblingParent(a, b *net.IPNet) (*net.IPNet, bool) {
+	aOnes, _ := a.Mask.Size()
+	bOnes, _ := b.Mask.Size()
+	if aOnes != bOnes || aOnes == 0 {
+		return nil, false
+	}
+
+	parentMask := net.CIDRMask(aOnes-1, 32)
+	parentIP := a.IP.Mask(parentMask)
+	if !a.IP.Equal(parentIP) {
+		return nil, false
+	}
+	if ipToUint32(b.IP) != ipToUint32(a.IP)+uint32(1)<<(32-aOnes) {
+		return nil, false
+	}
+	return &net.IPNet{IP: parentIP, Mask: parentMask}, true
+}
+
+// subtractCIDRs returns the smallest set of blocks covering the addresses of
+// block that aren't in any of the excluded blocks, sorted by address.
+func subtractCIDRs(block *net.IPNet, excluded []*net.IPNet) []*net.IPNet {
+	overlaps := false
+	for _, ex := range excluded {
+		if cidrContains(ex, block) {
+			return nil
+		}
+		if cidrContains(block, ex) {
+			overlaps = true
+		}
+	}
+	if !overlaps {
+		return []*net.IPNet{block}
+	}
+
+	// Two blocks are either nested or disjoint, so an excluded block is
+	// strictly inside this one: split it and keep what's left of each half.
+	lower, upper := splitCIDR(block)
+	return append(subtractCIDRs(lower, excluded), subtractCIDRs(upper, excluded)...)
+}
+
+// cidrContains reports whether inner is entirely within outer.
+func cidrContains(outer, inner *net.IPNet) bool {
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+	return outerBits == innerBits && outerOnes <= innerOnes && outer.Contains(inner.IP)
+}
+
+// splitCIDR splits an IPv4 block into its lower and upper halves. The block
+// must be shorter than /32.
+func splitCIDR(block *net.IPNet) (*net.IPNet, *net.IPNet) {
+	ones, _ := block.Mask.Size()
+	mask := net.CIDRMask(ones+1, 32)
+	lower := ipToUint32(block.IP)
+	upper := lower | uint32(1)<<(31-ones)
+	return &net.IPNet{IP: uint32ToIP(lower), Mask: mask}, &net.IPNet{IP: uint32ToIP(upper), Mask: mask}
+}
+
+// minimalCIDRs returns the smallest set of blocks covering exactly the given
+// IPv4 addresses.
+func minimalCIDRs(ips []net.IP) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, len(ips))
+	for i, ip := range ips {
+		ip4 := ip.To4()
+		if ip4 == nil {
+			return nil, fmt.Errorf("only IPv4 addresses are supported, got %s", ip)
+		}
+		nets[i] = &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return mergeCIDRs(nets), nil
+}
+
+// anchoredMinimalCIDRs is like minimalCIDRs, except that the addresses are
+// first grouped by the most specific anchor block they fall in, and the blocks
+// of each group are computed separately. The anchors thus stay distinct: no
+// block spans two anchors, even when they are both full.
+func anchoredMinimalCIDRs(ips []net.IP, anchors []*net.IPNet) ([]*net.IPNet, error) {
+	groups := make(map[*net.IPNet][]net.IP)
+	for _, ip := range ips {
+		var anchor *net.IPNet
+		for _, a := range anchors {
+			if a.Contains(ip) && (anchor == nil || cidrContains(anchor, a)) {
+				anchor = a
+			}
+		}
+		groups[anchor] = append(groups[anchor], ip)
+	}
+
+	var blocks []*net.IPNet
+	for _, group := range groups {
+		groupBlocks, err := minimalCIDRs(group)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, groupBlocks...)
+	}
+	slices.SortFunc(blocks, func(a, b *net.IPNet) int {
+		return compareIPs(a.IP, b.IP)
+	})
+	return blocks, nil
+}
+
+// floorCIDRs returns the blocks enclosing the given IPv4 addresses, none of
+// them shorter than /floor: the addresses are grouped by the /floor block they
+// fall in and each group gets its own enclosing block.
+func floorCIDRs(ips []net.IP, floor int) ([]*net.IPNet, error) {
+	mask, err := maskForPrefix(floor, 32)
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[uint32][]net.IP)
+	var keys []uint32
+	for _, ip := range ips {
+		ip4 := ip.To4()
+		if ip4 == nil {
+			return nil, fmt.Errorf("only IPv4 addresses are supported, got %s", ip)
+		}
+		key := ipToUint32(ip4.Mask(mask))
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], ip4)
+	}
+	slices.Sort(keys)
+
+	blocks := make([]*net.IPNet, 0, len(keys))
+	for _, key := range keys {
+		block, err := calculateIPNet(groups[key])
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// combineCIDRs returns the smallest IPv4 block containing both a and b. When
+// one of them contains the other, that is the one returned.
+func combineCIDRs(a, b *net.IPNet) (*net.IPNet, error) {
+	if a.IP.To4() == nil || b.IP.To4() == nil {
+		return nil, fmt.Errorf("only IPv4 blocks are supported")
+	}
+	if cidrContains(a, b) {
+		return a, nil
+	}
+	if cidrContains(b, a) {
+		return b, nil
+	}
+
+	minUint := min(ipToUint32(a.IP), ipToUint32(b.IP))
+	maxUint := max(ipToUint32(broadcastIP(a)), ipToUint32(broadcastIP(b)))
+	prefixLen := calculatePrefixLength(minUint, maxUint)
+	mask := net.CIDRMask(prefixLen, 32)
+	return &net.IPNet{IP: uint32ToIP(minUint).Mask(mask), Mask: mask}, nil
+}
+
+// isPartition reports whether the IPv4 blocks parts cover block exactly, with
+// no gaps and no overlaps, e.g. to validate a subnet plan. When they don't, it
+// also returns the problem regions sorted by address: the parts of block left
+// uncovered, the regions covered more than once and the regions of parts lying
+// outside of block.
+func isPartition(block net.IPNet, parts []net.IPNet) (bool, []net.IPNet) {
+	nets := make([]*net.IPNet, len(parts))
+	for i := range parts {
+		nets[i] = &parts[i]
+	}
+
+	problems := subtractCIDRs(&block, nets)
+	var overlaps []*net.IPNet
+	for i, a := range nets {
+		if !cidrContains(&block, a) {
+			problems = append(problems, subtractCIDRs(a, []*net.IPNet{&block})...)
+		}
+		// Two blocks overlap only when one of them contains the other.
+		for _, b := range nets[i+1:] {
+			if cidrContains(a, b) {
+				overlaps = append(overlaps, b)
+			} else if cidrContains(b, a) {
+				overlaps = append(overlaps, a)
+			}
+		}
+	}
+	problems = append(problems, mergeCIDRs(overlaps)...)
+	if len(problems) == 0 {
+		return true, nil
+	}
+
+	sorted := make([]net.IPNet, len(problems))
+	for i, p := range problems {
+		sorted[i] = *p
+	}
+	slices.SortFunc(sorted, func(a, b net.IPNet) int {
+		return compareIPs(a.IP, b.IP)
+	})
+	return false, sorted
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMergeCIDRs(t *testing.T) {
+	tests := []struct {
+		name  string
+		cidrs []string
+		want  []string
+	}{
+		{
+			name:  "single block",
+			cidrs: []string{"192.168.1.0/24"},
+			want:  []string{"192.168.1.0/24"},
+		},
+		{
+			name:  "siblings are joined",
+			cidrs: []string{"192.168.1.0/25", "192.168.1.128/25"},
+			want:  []string{"192.168.1.0/24"},
+		},
+		{
+			name:  "joined siblings keep folding",
+			cidrs: []string{"10.0.0.0/26", "10.0.0.64/26", "10.0.0.128/25", "10.0.1.0/24"},
+			want:  []string{"10.0.0.0/23"},
+		},
+		{
+			name:  "nested blocks are dropped",
+			cidrs: []string{"10.0.0.0/24", "10.0.0.0/8", "10.1.2.0/24"},
+			want:  []string{"10.0.0.0/8"},
+		},
+		{
+			name:  "adjacent blocks with different parents stay apart",
+			cidrs: []string{"192.168.1.128/25", "192.168.2.0/25"},
+			want:  []string{"192.168.1.128/25", "192.168.2.0/25"},
+		},
+		{
+			name:  "unsorted disjoint blocks are sorted",
+			cidrs: []string{"172.16.0.0/12", "10.0.0.0/8", "192.168.0.0/16"},
+			want:  []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"},
+		},
+		{
+			name:  "duplicates",
+			cidrs: []string{"10.0.0.0/24", "10.0.0.0/24"},
+			want:  []string{"10.0.0.0/24"},
+		},
+		{
+			name:  "empty",
+			cidrs: []string{},
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var nets []*net.IPNet
+			for _, c := range tt.cidrs {
+				_, n, err := net.ParseCIDR(c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				nets = append(nets, n)
+			}
+
+			got := mergeCIDRs(nets)
+			if len(got) != len(tt.want) {
+				t.Fatalf("mergeCIDRs() = %v, want %v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if got[i].String() != want {
+					t.Errorf("mergeCIDRs()[%d] = %v, want %v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSubtractCIDRs(t *testing.T) {
+	tests := []struct {
+		name     string
+		block    string
+		excluded []string
+		want     []string
+	}{
+		{
+			name:     "disjoint",
+			block:    "192.168.1.0/24",
+			excluded: []string{"10.0.0.0/8"},
+			want:     []string{"192.168.1.0/24"},
+		},
+		{
+			name:     "fully excluded",
+			block:    "192.168.1.0/24",
+			excluded: []string{"192.168.0.0/16"},
+			want:     []string{},
+		},
+		{
+			name:     "hole in the middle",
+			block:    "192.168.1.0/24",
+			excluded: []string{"192.168.1.64/26"},
+			want:     []string{"192.168.1.0/26", "192.168.1.128/25"},
+		},
+		{
+			name:     "several holes",
+			block:    "10.0.0.0/29",
+			excluded: []string{"10.0.0.0/32", "10.0.0.7/32"},
+			want:     []string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.6/32"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, block, err := net.ParseCIDR(tt.block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := subtractCIDRs(block, mustParseCIDRs(tt.excluded...))
+			if len(got) != len(tt.want) {
+				t.Fatalf("subtractCIDRs() = %v, want %v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if got[i].String() != want {
+					t.Errorf("subtractCIDRs()[%d] = %v, want %v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestMinimalCIDRs(t *testing.T) {
+	tests := []struct {
+		name    string
+		ips     []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "single IP",
+			ips:  []string{"192.168.1.1"},
+			want: []string{"192.168.1.1/32"},
+		},
+		{
+			name: "aligned run",
+			ips:  []string{"192.168.1.3", "192.168.1.0", "192.168.1.2", "192.168.1.1"},
+			want: []string{"192.168.1.0/30"},
+		},
+		{
+			name: "misaligned run",
+			ips:  []string{"192.168.1.1", "192.168.1.2", "192.168.1.3", "192.168.1.4"},
+			want: []string{"192.168.1.1/32", "192.168.1.2/31", "192.168.1.4/32"},
+		},
+		{
+			name:    "IPv6",
+			ips:     []string{"2001:db8::1"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ips []net.IP
+			for _, s := range tt.ips {
+				ips = append(ips, net.ParseIP(s))
+			}
+			got, err := minimalCIDRs(ips)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("minimalCIDRs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("minimalCIDRs() = %v, want %v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if got[i].String() != want {
+					t.Errorf("minimalCIDRs()[%d] = %v, want %v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestAnchoredMinimalCIDRs(t *testing.T) {
+	ips := []string{"10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.9"}
+	tests := []struct {
+		name    string
+		anchors []string
+		want    []string
+	}{
+		{
+			name: "no anchors",
+			want: []string{"10.0.0.0/30", "10.0.0.9/32"},
+		},
+		{
+			name:    "sibling anchors stay apart",
+			anchors: []string{"10.0.0.0/31", "10.0.0.2/31"},
+			want:    []string{"10.0.0.0/31", "10.0.0.2/31", "10.0.0.9/32"},
+		},
+		{
+			name:    "nested anchors",
+			anchors: []string{"10.0.0.0/24", "10.0.0.2/32"},
+			want:    []string{"10.0.0.0/31", "10.0.0.2/32", "10.0.0.3/32", "10.0.0.9/32"},
+		},
+		{
+			name:    "anchor holding no IPs",
+			anchors: []string{"192.168.0.0/16"},
+			want:    []string{"10.0.0.0/30", "10.0.0.9/32"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var parsed []net.IP
+			for _, s := range ips {
+				parsed = append(parsed, net.ParseIP(s))
+			}
+			got, err := anchoredMinimalCIDRs(parsed, mustParseCIDRs(tt.anchors...))
+			if err != nil {
+				t.Fatalf("anchoredMinimalCIDRs() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("anchoredMinimalCIDRs() = %v, want %v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if got[i].String() != want {
+					t.Errorf("anchoredMinimalCIDRs()[%d] = %v, want %v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunAnchor(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("192.168.1.0\n192.168.1.1\n192.168.1.2\n192.168.1.3\n")
+	code := run([]string{"-minimal", "-anchor", "192.168.1.0/31", "-anchor", "192.168.1.2/31"}, stdin, &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+	if want := "192.168.1.0/31\n192.168.1.2/31\n"; stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestFloorCIDRs(t *testing.T) {
+	tests := []struct {
+		name  string
+		ips   []string
+		floor int
+		want  []string
+	}{
+		{
+			name:  "one block per /16",
+			ips:   []string{"10.0.0.1", "10.0.0.5", "10.1.2.3", "10.200.0.1"},
+			floor: 16,
+			want:  []string{"10.0.0.0/29", "10.1.2.3/32", "10.200.0.1/32"},
+		},
+		{
+			name:  "IPs sharing a /16 keep their enclosing block",
+			ips:   []string{"10.5.0.1", "10.5.255.1", "10.6.0.1"},
+			floor: 16,
+			want:  []string{"10.5.0.0/16", "10.6.0.1/32"},
+		},
+		{
+			name:  "enclosing block already long enough",
+			ips:   []string{"192.168.1.1", "192.168.1.2"},
+			floor: 16,
+			want:  []string{"192.168.1.0/30"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ips []net.IP
+			for _, s := range tt.ips {
+				ips = append(ips, net.ParseIP(s))
+			}
+			blocks, err := floorCIDRs(ips, tt.floor)
+			if err != nil {
+				t.Fatalf("floorCIDRs() error = %v", err)
+			}
+			var got []string
+			for _, b := range blocks {
+				got = append(got, b.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("floorCIDRs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombineCIDRs(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		want    string
+		wantErr bool
+	}{
+		{name: "disjoint", a: "10.0.0.0/24", b: "10.0.3.0/24", want: "10.0.0.0/22"},
+		{name: "adjacent siblings", a: "10.0.0.0/24", b: "10.0.1.0/24", want: "10.0.0.0/23"},
+		{name: "adjacent across a boundary", a: "10.0.1.0/24", b: "10.0.2.0/24", want: "10.0.0.0/22"},
+		{name: "reversed order", a: "10.0.3.0/24", b: "10.0.0.0/24", want: "10.0.0.0/22"},
+		{name: "b nested in a", a: "10.0.0.0/16", b: "10.0.5.0/24", want: "10.0.0.0/16"},
+		{name: "a nested in b", a: "10.0.5.0/24", b: "10.0.0.0/16", want: "10.0.0.0/16"},
+		{name: "identical", a: "10.0.0.0/24", b: "10.0.0.0/24", want: "10.0.0.0/24"},
+		{name: "IPv6", a: "2001:db8::/64", b: "2001:db8:1::/64", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, a, _ := net.ParseCIDR(tt.a)
+			_, b, _ := net.ParseCIDR(tt.b)
+			got, err := combineCIDRs(a, b)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("combineCIDRs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("combineCIDRs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPartition(t *testing.T) {
+	tests := []struct {
+		name         string
+		block        string
+		parts        []string
+		want         bool
+		wantProblems []string
+	}{
+		{
+			name:  "valid partition",
+			block: "10.0.0.0/24",
+			parts: []string{"10.0.0.128/25", "10.0.0.0/26", "10.0.0.64/26"},
+			want:  true,
+		},
+		{
+			name:         "gap",
+			block:        "10.0.0.0/24",
+			parts:        []string{"10.0.0.0/26", "10.0.0.128/25"},
+			wantProblems: []string{"10.0.0.64/26"},
+		},
+		{
+			name:         "overlap",
+			block:        "10.0.0.0/24",
+			parts:        []string{"10.0.0.0/25", "10.0.0.64/26", "10.0.0.128/25"},
+			wantProblems: []string{"10.0.0.64/26"},
+		},
+		{
+			name:         "part outside of the block",
+			block:        "10.0.0.0/24",
+			parts:        []string{"10.0.0.0/24", "10.0.1.0/30"},
+			wantProblems: []string{"10.0.1.0/30"},
+		},
+		{
+			name:         "gap and overlap",
+			block:        "10.0.0.0/29",
+			parts:        []string{"10.0.0.0/30", "10.0.0.2/32", "10.0.0.6/31"},
+			wantProblems: []string{"10.0.0.2/32", "10.0.0.4/31"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, block, err := net.ParseCIDR(tt.block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var parts []net.IPNet
+			for _, part := range mustParseCIDRs(tt.parts...) {
+				parts = append(parts, *part)
+			}
+			got, problems := isPartition(*block, parts)
+			if got != tt.want {
+				t.Errorf("isPartition() = %v, want %v", got, tt.want)
+			}
+			if len(problems) != len(tt.wantProblems) {
+				t.Fatalf("isPartition() problems = %v, want %v", problems, tt.wantProblems)
+			}
+			for i, want := range tt.wantProblems {
+				if problems[i].String() != want {
+					t.Errorf("isPartition() problems[%d] = %v, want %v", i, problems[i].String(), want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// readMRTPrefixes reads the IPv4 prefixes of a BGP table dump, one per line
+// such as "192.0.2.0/24 64496 64511". Only the leading CIDR is read: the AS
+// path or anything else after it is ignored. Blank lines are skipped, and so
+// are invalid and IPv6 prefixes, the latter being counted in skippedIPv6.
+func readMRTPrefixes(reader io.Reader, opts parseOptions) (nets []*net.IPNet, skippedIPv6 int, err error) {
+	scanner := bufio.NewScanner(reader)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		_, ipnet, err := parseCIDR(fields[0])
+		if err != nil {
+			opts.invalid(atLine(lineNum, fmt.Sprintf("Invalid prefix: %s: %v", fields[0], err)))
+			continue
+		}
+		if ipnet.IP.To4() == nil {
+			skippedIPv6++
+			opts.invalid(atLine(lineNum, fmt.Sprintf("Only IPv4 prefixes are supported: %s", fields[0])))
+			continue
+		}
+		nets = append(nets, ipnet)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+	return nets, skippedIPv6, nil
+}
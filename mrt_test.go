@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadMRTPrefixes(t *testing.T) {
+	input := `192.0.2.0/25 64496 64511
+192.0.2.128/25	64496 64499 64511
+
+2001:db8::/32 64496
+not-a-prefix 64496
+198.51.100.0/24
+`
+	nets, skippedIPv6, err := readMRTPrefixes(strings.NewReader(input), parseOptions{})
+	if err != nil {
+		t.Fatalf("readMRTPrefixes() error = %v", err)
+	}
+	if skippedIPv6 != 1 {
+		t.Errorf("readMRTPrefixes() skipped %d IPv6 prefixes, want 1", skippedIPv6)
+	}
+	want := []string{"192.0.2.0/25", "192.0.2.128/25", "198.51.100.0/24"}
+	if len(nets) != len(want) {
+		t.Fatalf("readMRTPrefixes() = %v, want %v", nets, want)
+	}
+	for i, w := range want {
+		if nets[i].String() != w {
+			t.Errorf("readMRTPrefixes()[%d] = %v, want %v", i, nets[i], w)
+		}
+	}
+}
+
+func TestRunMRTInput(t *testing.T) {
+	input := `10.0.0.0/24 64496 64511 i
+10.0.1.0/24 64496 64500 64511 i
+10.0.1.128/25 64496 64511 i
+10.0.4.0/22 64496 64502 ?
+`
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-mrt-input"}, strings.NewReader(input), &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+	if want := "10.0.0.0/23\n10.0.4.0/22\n"; stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestReadMRTPrefixesReportsLineNumbers(t *testing.T) {
+	input := "192.0.2.0/25 64496\n\n192.0.2.0/33 64496\n"
+
+	var stderr bytes.Buffer
+	if _, _, err := readMRTPrefixes(strings.NewReader(input), parseOptions{debug: true, stderr: &stderr}); err != nil {
+		t.Fatalf("readMRTPrefixes() error = %v", err)
+	}
+	if want := "line 3: Invalid prefix: 192.0.2.0/33: prefix /33 out of range for IPv4"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("readMRTPrefixes() stderr = %q, want it to contain %q", stderr.String(), want)
+	}
+}
+
+func TestRunMRTInputMixedFamilies(t *testing.T) {
+	input := `10.0.0.0/24 64496 64511 i
+2001:db8::/33 64496 i
+10.0.1.0/24 64496 64500 i
+2001:db8:8000::/33 64496 i
+`
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-mrt-input"}, strings.NewReader(input), &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+	if want := "10.0.0.0/23\n"; stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+	if want := "Skipped 2 IPv6 prefixes: only IPv4 prefixes can be merged.\n"; stderr.String() != want {
+		t.Errorf("run() stderr = %q, want %q", stderr.String(), want)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// maxNmapTargetIPs caps the number of addresses a single octet-range target
+// can expand to, so that a target such as 0-255.0-255.0-255.0-255 doesn't
+// exhaust memory.
+const maxNmapTargetIPs = 1 << 16
+
+// parseNmapTargets returns the IPs denoted by a line of whitespace-separated
+// nmap targets. Targets without octet ranges are parsed like any input line;
+// the others, such as 192.168.1.1-50 or 10.0.1,3.0-7, expand to every
+// address they match.
+func parseNmapTargets(line string, opts parseOptions) ([]net.IP, bool) {
+	var ips []net.IP
+	for _, target := range strings.Fields(line) {
+		if !strings.ContainsAny(target, "-,") {
+			targetIPs, ok := parseAddress(target, opts)
+			if !ok {
+				return nil, false
+			}
+			ips = append(ips, targetIPs...)
+			continue
+		}
+
+		targetIPs, err := expandOctetRanges(target)
+		if err != nil {
+			opts.invalid(fmt.Sprintf("Invalid nmap target: %s: %v", target, err))
+			return nil, false
+		}
+		ips = append(ips, targetIPs...)
+	}
+	return ips, true
+}
+
+// expandOctetRanges returns the IPv4 addresses matched by an nmap target
+// whose octets are comma-separated lists of values and ranges, in order.
+func expandOctetRanges(target string) ([]net.IP, error) {
+	parts := strings.Split(target, ".")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("want 4 octets, got %d", len(parts))
+	}
+
+	octets := make([][]byte, 4)
+	total := 1
+	for i, part := range parts {
+		values, err := parseOctetList(part)
+		if err != nil {
+			return nil, err
+		}
+		octets[i] = values
+		total *= len(values)
+		if total > maxNmapTargetIPs {
+			return nil, fmt.Errorf("matches more than %d addresses", maxNmapTargetIPs)
+		}
+	}
+
+	ips := make([]net.IP, 0, total)
+	for _, a := range octets[0] {
+		for _, b := range octets[1] {
+			for _, c := range octets[2] {
+				for _, d := range octets[3] {
+					ips = append(ips, net.IPv4(a, b, c, d).To4())
+				}
+			}
+		}
+	}
+	return ips, nil
+}
+
+// parseOctetList parses an octet written as a comma-separated list of values
+// and inclusive ranges, e.g. "1,3,10-20".
+func parseOctetList(s string) ([]byte, error) {
+	var values []byte
+	for _, item := range strings.Split(s, ",") {
+		first, last, isRange := strings.Cut(item, "-")
+		lo, err := parseOctet(first)
+		if err != nil {
+			return nil, err
+		}
+		hi := lo
+		if isRange {
+			if hi, err = parseOctet(last); err != nil {
+				return nil, err
+			}
+			if hi < lo {
+				return nil, fmt.Errorf("range %s is reversed", item)
+			}
+		}
+		for v := int(lo); v <= int(hi); v++ {
+			values = append(values, byte(v))
+		}
+	}
+	return values, nil
+}
+
+// parseOctet parses a decimal octet between 0 and 255.
+func parseOctet(s string) (byte, error) {
+	v, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid octet %q", s)
+	}
+	return byte(v), nil
+}
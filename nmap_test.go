@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseNmapTargets(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   []string
+		wantOK bool
+	}{
+		{
+			name:   "single address",
+			line:   "192.168.1.7",
+			want:   []string{"192.168.1.7"},
+			wantOK: true,
+		},
+		{
+			name:   "CIDR",
+			line:   "192.168.1.0/30",
+			want:   []string{"192.168.1.0", "192.168.1.3"},
+			wantOK: true,
+		},
+		{
+			name:   "last octet range",
+			line:   "192.168.1.1-4",
+			want:   []string{"192.168.1.1", "192.168.1.2", "192.168.1.3", "192.168.1.4"},
+			wantOK: true,
+		},
+		{
+			name:   "lists and ranges in several octets",
+			line:   "10.0.1,3.5-6",
+			want:   []string{"10.0.1.5", "10.0.1.6", "10.0.3.5", "10.0.3.6"},
+			wantOK: true,
+		},
+		{
+			name:   "several targets",
+			line:   "192.168.1.1-2 10.0.0.1",
+			want:   []string{"192.168.1.1", "192.168.1.2", "10.0.0.1"},
+			wantOK: true,
+		},
+		{name: "reversed range", line: "192.168.1.50-1"},
+		{name: "octet out of range", line: "192.168.1.1-256"},
+		{name: "missing octet", line: "192.168.1-2"},
+		{name: "too many addresses", line: "10.0-255.0-255.0-255"},
+		{name: "invalid target", line: "192.168.1.1 nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseNmapTargets(tt.line, parseOptions{})
+			if ok != tt.wantOK {
+				t.Fatalf("parseNmapTargets() ok = %v, want %v", ok, tt.wantOK)
+			}
+			var want []net.IP
+			for _, s := range tt.want {
+				want = append(want, net.ParseIP(s))
+			}
+			if !slices.EqualFunc(got, want, net.IP.Equal) {
+				t.Errorf("parseNmapTargets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunNmapInput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("192.168.1.1-50\n")
+	code := run([]string{"-nmap-input", "-minimal", "-emit", "nmap"}, stdin, &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+
+	want := "192.168.1.1/32 192.168.1.2/31 192.168.1.4/30 192.168.1.8/29 192.168.1.16/28 192.168.1.32/28 192.168.1.48/31 192.168.1.50/32\n"
+	if stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+}
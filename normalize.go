@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// normalizeCIDRs reads one CIDR per line and returns each of them with its
+// host bits zeroed, e.g. 192.168.1.0/24 for 192.168.1.5/24, along with the
+// number of CIDRs that had host bits set. Blank and invalid lines are skipped.
+func normalizeCIDRs(reader io.Reader, opts parseOptions) ([]*net.IPNet, int, error) {
+	var nets []*net.IPNet
+	normalized := 0
+	scanner := bufio.NewScanner(reader)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		ip, ipnet, err := parseCIDR(line)
+		if err != nil {
+			if opts.debug {
+				debugLog(opts.stderr, atLine(lineNum, fmt.Sprintf("Invalid CIDR: %s: %v", line, err)))
+			}
+			continue
+		}
+		if !ip.Equal(ipnet.IP) {
+			normalized++
+			if opts.debug {
+				debugLog(opts.stderr, fmt.Sprintf("Normalized %s to %s", line, ipnet))
+			}
+		}
+		nets = append(nets, ipnet)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+	return nets, normalized, nil
+}
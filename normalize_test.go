@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeCIDRs(t *testing.T) {
+	input := strings.Join([]string{
+		"192.168.1.5/24",
+		"192.168.1.0/24",
+		"10.1.2.3/8",
+		"",
+		"not-a-cidr",
+		"172.16.5.4/12",
+		"2001:db8::1/32",
+		"192.168.1.7/32",
+	}, "\n")
+
+	nets, normalized, err := normalizeCIDRs(strings.NewReader(input), parseOptions{})
+	if err != nil {
+		t.Fatalf("normalizeCIDRs() error = %v", err)
+	}
+
+	want := []string{"192.168.1.0/24", "192.168.1.0/24", "10.0.0.0/8", "172.16.0.0/12", "2001:db8::/32", "192.168.1.7/32"}
+	if len(nets) != len(want) {
+		t.Fatalf("normalizeCIDRs() = %v, want %v", nets, want)
+	}
+	for i := range want {
+		if nets[i].String() != want[i] {
+			t.Errorf("normalizeCIDRs()[%d] = %v, want %v", i, nets[i], want[i])
+		}
+	}
+	if normalized != 4 {
+		t.Errorf("normalizeCIDRs() normalized = %d, want 4", normalized)
+	}
+}
+
+func TestNormalizeCIDRsReportsLineNumbers(t *testing.T) {
+	input := "192.168.1.0/24\n\n\n10.0.0.0/33\n"
+
+	var stderr bytes.Buffer
+	if _, _, err := normalizeCIDRs(strings.NewReader(input), parseOptions{debug: true, stderr: &stderr}); err != nil {
+		t.Fatalf("normalizeCIDRs() error = %v", err)
+	}
+	if want := "line 4: Invalid CIDR: 10.0.0.0/33: prefix /33 out of range for IPv4"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("normalizeCIDRs() stderr = %q, want it to contain %q", stderr.String(), want)
+	}
+}
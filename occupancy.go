@@ -0,0 +1,43 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"slices"
+)
+
+// subnetCount is the number of input IPs falling in a block.
+type subnetCount struct {
+	block *net.IPNet
+	count int
+}
+
+// per24Counts groups the IPv4 addresses by the /24 they fall in and returns
+// each /24 with its number of addresses, the most populated first. /24s
+// holding as many addresses are sorted by address.
+func per24Counts(ips []net.IP) ([]subnetCount, error) {
+	counts := make(map[uint32]int)
+	for _, ip := range ips {
+		u, ok := ipv4ToUint32(ip)
+		if !ok {
+			return nil, fmt.Errorf("only IPv4 addresses are supported, got %s", ip)
+		}
+		counts[u&0xffffff00]++
+	}
+
+	subnets := make([]subnetCount, 0, len(counts))
+	for network, count := range counts {
+		subnets = append(subnets, subnetCount{
+			block: &net.IPNet{IP: uint32ToIP(network), Mask: net.CIDRMask(24, 32)},
+			count: count,
+		})
+	}
+	slices.SortFunc(subnets, func(a, b subnetCount) int {
+		if c := cmp.Compare(b.count, a.count); c != 0 {
+			return c
+		}
+		return compareIPs(a.block.IP, b.block.IP)
+	})
+	return subnets, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestPer24Counts(t *testing.T) {
+	var ips []net.IP
+	for _, s := range []string{"10.0.1.1", "10.0.2.1", "10.0.2.7", "10.0.2.200", "10.0.3.4", "10.0.1.9", "10.0.0.5"} {
+		ips = append(ips, net.ParseIP(s))
+	}
+
+	got, err := per24Counts(ips)
+	if err != nil {
+		t.Fatalf("per24Counts() error = %v", err)
+	}
+	want := []struct {
+		block string
+		count int
+	}{
+		{"10.0.2.0/24", 3},
+		{"10.0.1.0/24", 2},
+		{"10.0.0.0/24", 1},
+		{"10.0.3.0/24", 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("per24Counts() returned %d /24s, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].block.String() != w.block || got[i].count != w.count {
+			t.Errorf("per24Counts()[%d] = %s %d, want %s %d", i, got[i].block, got[i].count, w.block, w.count)
+		}
+	}
+
+	if _, err := per24Counts([]net.IP{net.ParseIP("2001:db8::1")}); err == nil {
+		t.Error("per24Counts() of an IPv6 address succeeded, want an error")
+	}
+}
+
+func TestRunPer24(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("192.168.1.1\n192.168.7.1\n192.168.7.2\n")
+	code := run([]string{"-per-24"}, stdin, &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+	if want := "192.168.7.0/24 2\n192.168.1.0/24 1\n"; stderr.String() != want {
+		t.Errorf("run() stderr = %q, want %q", stderr.String(), want)
+	}
+	if want := "192.168.0.0/21\n"; stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+}
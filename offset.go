@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"net"
+)
+
+// subnetIndex returns the position of block among the subnets of its size
+// within base, counting from 0: 192.168.1.64/26 is subnet 1 of /26 within
+// 192.168.1.0/24.
+func subnetIndex(base, block *net.IPNet) (int, error) {
+	baseOnes, baseBits := base.Mask.Size()
+	ones, bits := block.Mask.Size()
+	if baseBits != 32 || bits != 32 {
+		return 0, fmt.Errorf("only IPv4 blocks are supported")
+	}
+	if !cidrContains(base, block) {
+		return 0, fmt.Errorf("%s is not within %s", block, base)
+	}
+	if ones-baseOnes > 31 {
+		return 0, fmt.Errorf("%s has too many subnets of /%d to be indexed", base, ones)
+	}
+	diff := ipToUint32(block.IP) - ipToUint32(base.IP)
+	return int(diff >> (32 - ones)), nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestSubnetIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		block   string
+		want    int
+		wantErr bool
+	}{
+		{name: "first /26", base: "192.168.1.0/24", block: "192.168.1.0/26", want: 0},
+		{name: "second /26", base: "192.168.1.0/24", block: "192.168.1.64/26", want: 1},
+		{name: "last /26", base: "192.168.1.0/24", block: "192.168.1.192/26", want: 3},
+		{name: "base itself", base: "192.168.1.0/24", block: "192.168.1.0/24", want: 0},
+		{name: "host", base: "10.0.0.0/8", block: "10.0.1.5/32", want: 261},
+		{name: "outside of base", base: "192.168.1.0/24", block: "192.168.2.0/26", wantErr: true},
+		{name: "wider than base", base: "192.168.1.0/24", block: "192.168.0.0/16", wantErr: true},
+		{name: "too many subnets", base: "0.0.0.0/0", block: "10.0.0.1/32", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, base, _ := net.ParseCIDR(tt.base)
+			_, block, _ := net.ParseCIDR(tt.block)
+			got, err := subnetIndex(base, block)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("subnetIndex() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("subnetIndex() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
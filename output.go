@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net"
+	"strconv"
+)
+
+// outputOptions controls how the resulting blocks are printed.
+type outputOptions struct {
+	jsonl bool // Print a JSON object per block.
+	json  bool // Print a JSON object with the details of each block.
+	csv   bool // Print a CSV row with the details of each block.
+	count bool // Print the number of usable hosts next to the block.
+
+	indent bool // Pretty-print the JSON objects of json.
+
+	prefixOnly bool // Print the prefix length instead of the block.
+	canonical  bool // Zero the host bits of the blocks before printing them, as a safeguard.
+
+	labels         []string // Names printed before the blocks, the ith for the ith block.
+	allowUnlabeled bool     // Print the blocks past the last label without one, rather than fail.
+
+	offsetFrom *net.IPNet // Print the index of the block among the subnets of its size within offsetFrom.
+
+	emit    string // One of emitFormats, or "" for one block per line.
+	setName string // Name of the nftables set.
+	via     net.IP // Gateway of the routes printed for -emit iproute.
+	group   string // Name of the Ansible inventory group.
+}
+
+// writeBlocks prints the blocks resulting from one aggregation to w.
+func writeBlocks(w io.Writer, blocks []*net.IPNet, opts outputOptions) error {
+	if opts.canonical {
+		blocks = canonicalCIDRs(blocks)
+	}
+	switch opts.emit {
+	case "nftables":
+		return emitNftables(w, blocks, opts.setName)
+	case "hcl":
+		return emitHCL(w, blocks)
+	case "iproute":
+		return emitIPRoute(w, blocks, opts.via)
+	case "python":
+		return emitPython(w, blocks)
+	case "ansible":
+		return emitAnsible(w, blocks, opts.group)
+	case "nmap":
+		return emitNmap(w, blocks)
+	case "bash":
+		return emitBash(w, blocks)
+	case "cisco-acl":
+		return emitCiscoACL(w, blocks)
+	}
+
+	if opts.labels != nil && len(blocks) > len(opts.labels) && !opts.allowUnlabeled {
+		return fmt.Errorf("%d labels for %d blocks", len(opts.labels), len(blocks))
+	}
+	for i, block := range blocks {
+		if i < len(opts.labels) {
+			if _, err := fmt.Fprintf(w, "%s: ", opts.labels[i]); err != nil {
+				return err
+			}
+		}
+		if err := writeBlock(w, block, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// headBlocks returns the first n blocks, or all of them when n is 0, along
+// with the number of blocks left out.
+func headBlocks(blocks []*net.IPNet, n int) ([]*net.IPNet, int) {
+	if n == 0 || len(blocks) <= n {
+		return blocks, 0
+	}
+	return blocks[:n], len(blocks) - n
+}
+
+// canonicalCIDRs returns a copy of blocks with their host bits zeroed.
+func canonicalCIDRs(blocks []*net.IPNet) []*net.IPNet {
+	canonical := make([]*net.IPNet, len(blocks))
+	for i, block := range blocks {
+		canonical[i] = canonicalCIDR(block)
+	}
+	return canonical
+}
+
+// writeBlock prints a resulting block to w on its own line.
+func writeBlock(w io.Writer, block *net.IPNet, opts outputOptions) error {
+	if opts.jsonl {
+		return writeJSONLResult(w, block.String())
+	}
+	if opts.json {
+		return writeJSONResult(w, block, opts.indent)
+	}
+	if opts.csv {
+		return writeCSVResult(w, block)
+	}
+	prefixLen, bits := block.Mask.Size()
+	label := block.String()
+	if opts.prefixOnly {
+		label = strconv.Itoa(prefixLen)
+	}
+	if opts.count {
+		if bits != 32 {
+			return fmt.Errorf("-count only supports IPv4 blocks")
+		}
+		_, err := fmt.Fprintf(w, "%s (usable hosts: %d)\n", label, usableHosts(prefixLen))
+		return err
+	}
+	if opts.offsetFrom != nil {
+		index, err := subnetIndex(opts.offsetFrom, block)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s (subnet %d of /%d within %s)\n", block, index, prefixLen, opts.offsetFrom)
+		return err
+	}
+	_, err := fmt.Fprintln(w, label)
+	return err
+}
+
+// utilization returns the percentage of the addresses of block that the
+// unique input IPs fill. The block size is computed exactly, as a /0 holds more
+// addresses than fit in an integer.
+func utilization(unique int, block *net.IPNet) float64 {
+	ratio := new(big.Float).Quo(big.NewFloat(float64(unique)), new(big.Float).SetInt(blockSize(block)))
+	percent, _ := ratio.Mul(ratio, big.NewFloat(100)).Float64()
+	return percent
+}
+
+// summaryLine describes how well an aggregation went: how many IPs were read,
+// how many of them were unique, and which share of the block they fill.
+func summaryLine(total, unique int, block *net.IPNet) string {
+	ones, bits := block.Mask.Size()
+	capacity := math.Ldexp(1, bits-ones)
+	pct := math.Round(utilization(unique, block))
+	return fmt.Sprintf("aggregated %d IPs (%d unique) into %s covering %.0f addresses (%.0f%% utilization)",
+		total, unique, block, capacity, pct)
+}
+
+// newlineTrimmer writes everything written to it to w, except for a final
+// newline: a newline ending a write is held back until more output follows,
+// and dropped if none does.
+type newlineTrimmer struct {
+	w       io.Writer
+	pending bool
+}
+
+func (t *newlineTrimmer) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if t.pending {
+		if _, err := t.w.Write([]byte{'\n'}); err != nil {
+			return 0, err
+		}
+		t.pending = false
+	}
+
+	held := p[len(p)-1] == '\n'
+	if held {
+		p = p[:len(p)-1]
+	}
+	n, err := t.w.Write(p)
+	if held && err == nil {
+		t.pending = true
+		n++
+	}
+	return n, err
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestWriteBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidr    string
+		opts    outputOptions
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", cidr: "192.168.1.0/24", want: "192.168.1.0/24\n"},
+		{name: "jsonl", cidr: "192.168.1.0/24", opts: outputOptions{jsonl: true}, want: "{\"cidr\":\"192.168.1.0/24\"}\n"},
+		{name: "count", cidr: "192.168.1.0/24", opts: outputOptions{count: true}, want: "192.168.1.0/24 (usable hosts: 254)\n"},
+		{name: "count of IPv6", cidr: "2001:db8::/64", opts: outputOptions{count: true}, wantErr: true},
+		{name: "prefix only", cidr: "192.168.1.0/24", opts: outputOptions{prefixOnly: true}, want: "24\n"},
+		{name: "prefix only with count", cidr: "192.168.1.0/24", opts: outputOptions{prefixOnly: true, count: true}, want: "24 (usable hosts: 254)\n"},
+		{name: "prefix only of IPv6", cidr: "2001:db8::/64", opts: outputOptions{prefixOnly: true}, want: "64\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, block, err := net.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			err = writeBlock(&buf, block, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeBlock() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("writeBlock() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteBlocksCanonical(t *testing.T) {
+	blocks := []*net.IPNet{
+		{IP: net.IPv4(192, 168, 1, 57).To4(), Mask: net.CIDRMask(24, 32)},
+		{IP: net.IPv4(10, 1, 2, 3).To4(), Mask: net.CIDRMask(32, 32)},
+	}
+	tests := []struct {
+		name string
+		opts outputOptions
+		want string
+	}{
+		{name: "as is", want: "192.168.1.57/24\n10.1.2.3/32\n"},
+		{name: "canonical", opts: outputOptions{canonical: true}, want: "192.168.1.0/24\n10.1.2.3/32\n"},
+		{name: "canonical hcl", opts: outputOptions{canonical: true, emit: "hcl"}, want: "cidr_blocks = [\"192.168.1.0/24\", \"10.1.2.3/32\"]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeBlocks(&buf, blocks, tt.opts); err != nil {
+				t.Fatalf("writeBlocks() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("writeBlocks() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRunCanonical(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantStdout string
+	}{
+		{
+			name:       "floor prefix",
+			args:       []string{"-floor-prefix", "24"},
+			stdin:      "10.0.0.57\n10.0.0.200\n10.0.9.3\n10.0.9.6\n",
+			wantStdout: "10.0.0.0/24\n10.0.9.0/29\n",
+		},
+		{
+			name:       "split",
+			args:       []string{"-split", "26"},
+			stdin:      "192.168.1.57\n192.168.1.200\n",
+			wantStdout: "192.168.1.0/26\n192.168.1.64/26\n192.168.1.128/26\n192.168.1.192/26\n",
+		},
+		{
+			name:       "single IP",
+			stdin:      "192.168.1.57\n",
+			wantStdout: "192.168.1.57/32\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The blocks computed are already canonical, so -canonical
+			// must print them unchanged.
+			for _, args := range [][]string{tt.args, append([]string{"-canonical"}, tt.args...)} {
+				var stdout, stderr bytes.Buffer
+				code := run(args, strings.NewReader(tt.stdin), &stdout, &stderr)
+				if code != ExitOK {
+					t.Fatalf("run(%q) = %d, want %d (stderr: %q)", args, code, ExitOK, stderr.String())
+				}
+				if stdout.String() != tt.wantStdout {
+					t.Errorf("run(%q) stdout = %q, want %q", args, stdout.String(), tt.wantStdout)
+				}
+			}
+		})
+	}
+}
+
+func TestSummaryLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		total  int
+		unique int
+		cidr   string
+		want   string
+	}{
+		{
+			name:   "IPv4",
+			total:  1234,
+			unique: 1200,
+			cidr:   "192.168.0.0/20",
+			want:   "aggregated 1234 IPs (1200 unique) into 192.168.0.0/20 covering 4096 addresses (29% utilization)",
+		},
+		{
+			name:   "single address",
+			total:  2,
+			unique: 1,
+			cidr:   "10.0.0.1/32",
+			want:   "aggregated 2 IPs (1 unique) into 10.0.0.1/32 covering 1 addresses (100% utilization)",
+		},
+		{
+			name:   "IPv6",
+			total:  2,
+			unique: 2,
+			cidr:   "2001:db8::/126",
+			want:   "aggregated 2 IPs (2 unique) into 2001:db8::/126 covering 4 addresses (50% utilization)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, block, err := net.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := summaryLine(tt.total, tt.unique, block); got != tt.want {
+				t.Errorf("summaryLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUtilization(t *testing.T) {
+	tests := []struct {
+		name   string
+		unique int
+		cidr   string
+		want   float64
+	}{
+		{name: "full block", unique: 4, cidr: "192.168.1.0/30", want: 100},
+		{name: "sparse block", unique: 2, cidr: "192.168.1.0/24", want: 0.78125},
+		{name: "IPv4 /0", unique: 1 << 30, cidr: "0.0.0.0/0", want: 25},
+		{name: "IPv6 /0", unique: 1, cidr: "::/0", want: 100 / math.Ldexp(1, 128)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, block, err := net.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := utilization(tt.unique, block); got != tt.want {
+				t.Errorf("utilization() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunUtilizationThreshold(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantStderr string
+	}{
+		{
+			name:       "sparse input",
+			input:      "192.168.1.1\n192.168.1.200\n",
+			wantStderr: "Warning: the input IPs fill only 0.78% of 192.168.1.0/24, below -utilization-threshold 50%; -minimal would print a tighter set of blocks\n",
+		},
+		{
+			name:  "dense input",
+			input: "192.168.1.0\n192.168.1.1\n192.168.1.2\n192.168.1.3\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"-utilization-threshold", "50"}, strings.NewReader(tt.input), &stdout, &stderr)
+			if code != ExitOK {
+				t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("run() stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestNewlineTrimmer(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{name: "single line", writes: []string{"192.168.1.0/24\n"}, want: "192.168.1.0/24"},
+		{name: "several lines", writes: []string{"10.0.0.0/31\n", "10.0.0.9/32\n"}, want: "10.0.0.0/31\n10.0.0.9/32"},
+		{name: "line written in pieces", writes: []string{"10.0.0.0", "/31", "\n"}, want: "10.0.0.0/31"},
+		{name: "blank lines", writes: []string{"a\n", "\n", "\n"}, want: "a\n\n"},
+		{name: "no final newline", writes: []string{"a\nb"}, want: "a\nb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := &newlineTrimmer{w: &buf}
+			for _, s := range tt.writes {
+				n, err := w.Write([]byte(s))
+				if err != nil || n != len(s) {
+					t.Fatalf("Write(%q) = %d, %v, want %d, nil", s, n, err, len(s))
+				}
+			}
+			if buf.String() != tt.want {
+				t.Errorf("newlineTrimmer wrote %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRunNoNewline(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		input string
+		want  []byte
+	}{
+		{
+			name:  "with the newline",
+			input: "192.168.1.1\n192.168.1.2\n",
+			want:  []byte("192.168.1.0/30\n"),
+		},
+		{
+			name:  "without the newline",
+			args:  []string{"-no-newline"},
+			input: "192.168.1.1\n192.168.1.2\n",
+			want:  []byte("192.168.1.0/30"),
+		},
+		{
+			name:  "only the final line",
+			args:  []string{"-no-newline", "-minimal"},
+			input: "192.168.1.0\n192.168.1.1\n192.168.1.9\n",
+			want:  []byte("192.168.1.0/31\n192.168.1.9/32"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, strings.NewReader(tt.input), &stdout, &stderr)
+			if code != ExitOK {
+				t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+			}
+			if !bytes.Equal(stdout.Bytes(), tt.want) {
+				t.Errorf("run() stdout = %q, want %q", stdout.Bytes(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHeadBlocks(t *testing.T) {
+	blocks := mustParseCIDRs("10.0.0.0/32", "10.0.0.2/32", "10.0.0.4/32")
+	tests := []struct {
+		name        string
+		n           int
+		wantLen     int
+		wantOmitted int
+	}{
+		{name: "no limit", n: 0, wantLen: 3},
+		{name: "above the count", n: 5, wantLen: 3},
+		{name: "equal to the count", n: 3, wantLen: 3},
+		{name: "below the count", n: 2, wantLen: 2, wantOmitted: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, omitted := headBlocks(blocks, tt.n)
+			if len(got) != tt.wantLen || omitted != tt.wantOmitted {
+				t.Errorf("headBlocks() = %v, %d, want %d blocks, %d omitted", got, omitted, tt.wantLen, tt.wantOmitted)
+			}
+		})
+	}
+}
+
+func TestRunHead(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("10.0.0.0\n10.0.0.2\n10.0.0.4\n10.0.0.6\n10.0.0.8\n")
+	code := run([]string{"-minimal", "-head", "2"}, stdin, &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+	if want := "10.0.0.0/32\n10.0.0.2/32\n"; stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+	if want := "Omitted 3 more blocks because of -head.\n"; stderr.String() != want {
+		t.Errorf("run() stderr = %q, want %q", stderr.String(), want)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strings"
+)
+
+// outputFormats lists the formats accepted by -formats.
+var outputFormats = []string{"text", "json", "csv"}
+
+// formatPlaceholder is replaced by the name of each format in the -out path
+// template.
+const formatPlaceholder = "{format}"
+
+// output is a destination for the resulting blocks, along with the options
+// selecting how they are printed there.
+type output struct {
+	w    io.Writer
+	opts outputOptions
+}
+
+// parseFormats parses the comma-separated list given to -formats.
+func parseFormats(s string) ([]string, error) {
+	var formats []string
+	for _, format := range strings.Split(s, ",") {
+		format = strings.TrimSpace(format)
+		if !slices.Contains(outputFormats, format) {
+			return nil, fmt.Errorf("unknown format %q, must be one of: %s", format, strings.Join(outputFormats, ", "))
+		}
+		if slices.Contains(formats, format) {
+			return nil, fmt.Errorf("format %q listed twice", format)
+		}
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+// createOutput creates the file at path for openOutputs. Tests replace it to
+// simulate failing writes.
+var createOutput = func(path string) (io.WriteCloser, error) { return os.Create(path) }
+
+// openOutputs creates one file per format, at the path given by template with
+// {format} replaced by the name of the format. The blocks are printed to each
+// file with opts, switched to that format. close closes all the files.
+func openOutputs(formats []string, template string, opts outputOptions) (outputs []output, close func() error, err error) {
+	if len(formats) > 1 && !strings.Contains(template, formatPlaceholder) {
+		return nil, nil, fmt.Errorf("path %q must contain %s to tell the formats apart", template, formatPlaceholder)
+	}
+
+	var files []io.WriteCloser
+	close = func() error {
+		var errs []error
+		for _, f := range files {
+			errs = append(errs, f.Close())
+		}
+		return errors.Join(errs...)
+	}
+	for _, format := range formats {
+		f, err := createOutput(strings.ReplaceAll(template, formatPlaceholder, format))
+		if err != nil {
+			close()
+			return nil, nil, err
+		}
+		files = append(files, f)
+
+		out := output{w: f, opts: opts}
+		switch format {
+		case "json":
+			out.opts.json = true
+		case "csv":
+			out.opts.csv = true
+			if err := writeCSVHeader(f); err != nil {
+				close()
+				return nil, nil, err
+			}
+		}
+		outputs = append(outputs, out)
+	}
+	return outputs, close, nil
+}
+
+// writeOutputs prints the blocks resulting from one aggregation to each of the
+// outputs.
+func writeOutputs(outputs []output, blocks []*net.IPNet) error {
+	for _, out := range outputs {
+		if err := writeBlocks(out.w, blocks, out.opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
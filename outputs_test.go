@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []string
+		wantErr bool
+	}{
+		{name: "single format", s: "json", want: []string{"json"}},
+		{name: "several formats", s: "json, csv,text", want: []string{"json", "csv", "text"}},
+		{name: "unknown format", s: "json,xml", wantErr: true},
+		{name: "duplicate format", s: "csv,csv", wantErr: true},
+		{name: "empty", s: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFormats(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFormats() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseFormats() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunFormats(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("192.168.1.1\n192.168.1.50\n192.168.1.200\n")
+	code := run([]string{"-formats", "json,csv", "-out", filepath.Join(dir, "results.{format}")}, stdin, &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("run() stdout = %q, want nothing", stdout.String())
+	}
+
+	want := map[string]string{
+		"results.json": "{\"cidr\":\"192.168.1.0/24\",\"prefix\":24,\"network\":\"192.168.1.0\",\"broadcast\":\"192.168.1.255\",\"hosts\":254}\n",
+		"results.csv":  "cidr,prefix,network,broadcast,hosts\n192.168.1.0/24,24,192.168.1.0,192.168.1.255,254\n",
+	}
+	for name, want := range want {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRunFormatsNeedsPlaceholder(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	out := filepath.Join(t.TempDir(), "results.txt")
+	code := run([]string{"-formats", "json,csv", "-out", out}, strings.NewReader("10.0.0.1\n"), &stdout, &stderr)
+	if code != ExitFailure {
+		t.Errorf("run() = %d, want %d", code, ExitFailure)
+	}
+}
+
+// failingCloser is an output file whose contents are lost when closed.
+type failingCloser struct {
+	bytes.Buffer
+}
+
+func (*failingCloser) Close() error { return errors.New("disk full") }
+
+func TestRunFormatsFailsWhenOutputCannotBeClosed(t *testing.T) {
+	orig := createOutput
+	createOutput = func(string) (io.WriteCloser, error) { return &failingCloser{}, nil }
+	defer func() { createOutput = orig }()
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-formats", "json", "-out", "results.json"}, strings.NewReader("10.0.0.1\n"), &stdout, &stderr)
+	if code != ExitFailure {
+		t.Errorf("run() = %d, want %d", code, ExitFailure)
+	}
+	if want := "Error writing output: disk full"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("run() stderr = %q, want it to contain %q", stderr.String(), want)
+	}
+}
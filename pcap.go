@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io"
+	"net"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// readPcapIPs returns the source and destination addresses of every IPv4
+// packet in a pcap capture, in capture order. Packets without an IPv4 header
+// are skipped.
+func readPcapIPs(reader io.Reader) ([]net.IP, error) {
+	pcap, err := pcapgo.NewReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	for {
+		data, _, err := pcap.ReadPacketData()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		packet := gopacket.NewPacket(data, pcap.LinkType(), gopacket.DecodeOptions{Lazy: true, NoCopy: true})
+		ipv4, ok := packet.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+		if !ok {
+			continue
+		}
+		ips = append(ips, copyIP(ipv4.SrcIP), copyIP(ipv4.DstIP))
+	}
+	return ips, nil
+}
+
+// copyIP returns a copy of ip that doesn't alias the packet buffer.
+func copyIP(ip net.IP) net.IP {
+	return append(net.IP(nil), ip...)
+}
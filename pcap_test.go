@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// writePcapFixture builds a tiny Ethernet capture holding the given layers,
+// one packet per entry.
+func writePcapFixture(t *testing.T, packets [][]gopacket.SerializableLayer) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := pcapgo.NewWriter(&buf)
+	if err := w.WriteFileHeader(65535, layers.LinkTypeEthernet); err != nil {
+		t.Fatal(err)
+	}
+	for _, packet := range packets {
+		data := gopacket.NewSerializeBuffer()
+		opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+		if err := gopacket.SerializeLayers(data, opts, packet...); err != nil {
+			t.Fatal(err)
+		}
+		ci := gopacket.CaptureInfo{CaptureLength: len(data.Bytes()), Length: len(data.Bytes())}
+		if err := w.WritePacket(ci, data.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func ipv4Packet(src, dst string) []gopacket.SerializableLayer {
+	return []gopacket.SerializableLayer{
+		&layers.Ethernet{
+			SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
+			DstMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 2},
+			EthernetType: layers.EthernetTypeIPv4,
+		},
+		&layers.IPv4{
+			Version:  4,
+			TTL:      64,
+			Protocol: layers.IPProtocolUDP,
+			SrcIP:    net.ParseIP(src).To4(),
+			DstIP:    net.ParseIP(dst).To4(),
+		},
+		gopacket.Payload("hello"),
+	}
+}
+
+func TestReadPcapIPs(t *testing.T) {
+	arp := []gopacket.SerializableLayer{
+		&layers.Ethernet{
+			SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
+			DstMAC:       net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			EthernetType: layers.EthernetTypeARP,
+		},
+		&layers.ARP{
+			AddrType:          layers.LinkTypeEthernet,
+			Protocol:          layers.EthernetTypeIPv4,
+			HwAddressSize:     6,
+			ProtAddressSize:   4,
+			Operation:         layers.ARPRequest,
+			SourceHwAddress:   []byte{0, 0, 0, 0, 0, 1},
+			SourceProtAddress: []byte{172, 16, 0, 1},
+			DstHwAddress:      []byte{0, 0, 0, 0, 0, 0},
+			DstProtAddress:    []byte{172, 16, 0, 2},
+		},
+	}
+	capture := writePcapFixture(t, [][]gopacket.SerializableLayer{
+		ipv4Packet("192.168.1.10", "192.168.1.20"),
+		arp,
+		ipv4Packet("192.168.1.30", "192.168.1.200"),
+	})
+
+	ips, err := readPcapIPs(bytes.NewReader(capture))
+	if err != nil {
+		t.Fatalf("readPcapIPs() error = %v", err)
+	}
+
+	want := []string{"192.168.1.10", "192.168.1.20", "192.168.1.30", "192.168.1.200"}
+	if len(ips) != len(want) {
+		t.Fatalf("readPcapIPs() = %v, want %v", ips, want)
+	}
+	for i := range want {
+		if ips[i].String() != want[i] {
+			t.Errorf("readPcapIPs() IP[%d] = %v, want %v", i, ips[i], want[i])
+		}
+	}
+
+	got, err := calculateCIDR(ips)
+	if err != nil {
+		t.Fatalf("calculateCIDR() error = %v", err)
+	}
+	if got != "192.168.1.0/24" {
+		t.Errorf("calculateCIDR() = %v, want 192.168.1.0/24", got)
+	}
+}
+
+func TestReadPcapIPsNotAPcap(t *testing.T) {
+	if _, err := readPcapIPs(bytes.NewReader([]byte("192.168.1.1\n"))); err == nil {
+		t.Error("readPcapIPs() error = nil, want an error for a non-pcap input")
+	}
+}
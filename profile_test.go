@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunWritesProfiles(t *testing.T) {
+	dir := t.TempDir()
+	cpuPath := filepath.Join(dir, "cpu.pprof")
+	memPath := filepath.Join(dir, "mem.pprof")
+
+	tests := []struct {
+		name     string
+		stdin    string
+		wantCode int
+	}{
+		{name: "success", stdin: "192.168.1.1\n192.168.1.2\n", wantCode: ExitOK},
+		{name: "no input", stdin: "", wantCode: ExitNoInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(cpuPath)
+			os.Remove(memPath)
+
+			var stdout, stderr bytes.Buffer
+			args := []string{"-cpuprofile", cpuPath, "-memprofile", memPath}
+			if code := run(args, strings.NewReader(tt.stdin), &stdout, &stderr); code != tt.wantCode {
+				t.Fatalf("run() = %d, want %d (stderr: %q)", code, tt.wantCode, stderr.String())
+			}
+
+			for _, path := range []string{cpuPath, memPath} {
+				info, err := os.Stat(path)
+				if err != nil {
+					t.Fatalf("profile not written: %v", err)
+				}
+				if info.Size() == 0 {
+					t.Errorf("profile %s is empty", filepath.Base(path))
+				}
+			}
+		})
+	}
+}
+
+func TestRunFailsWhenProfileCannotBeWritten(t *testing.T) {
+	memPath := filepath.Join(t.TempDir(), "missing", "mem.pprof")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-memprofile", memPath}, strings.NewReader("192.168.1.1\n"), &stdout, &stderr)
+	if code != ExitFailure {
+		t.Errorf("run() = %d, want %d", code, ExitFailure)
+	}
+	if !strings.Contains(stderr.String(), "Error writing profile") {
+		t.Errorf("run() stderr = %q, want an error about the profile", stderr.String())
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// progressReporter prints how many input lines were read so far, so that
+// users know a large input is being worked on.
+type progressReporter struct {
+	w        io.Writer
+	every    int           // Consider reporting every that many lines.
+	interval time.Duration // Report at most once per interval.
+	now      func() time.Time
+
+	lines int
+	last  time.Time
+}
+
+// newProgressReporter returns a reporter writing to w every that many lines,
+// at most once per interval.
+func newProgressReporter(w io.Writer, every int, interval time.Duration) *progressReporter {
+	return &progressReporter{w: w, every: every, interval: interval, now: time.Now}
+}
+
+// reset starts counting from zero again, e.g. for the next -batch group. It
+// does nothing on a nil reporter.
+func (p *progressReporter) reset() {
+	if p == nil {
+		return
+	}
+	p.lines = 0
+	p.last = time.Time{}
+}
+
+// line counts a line read from the input, printing the count when due. It
+// does nothing on a nil reporter.
+func (p *progressReporter) line() {
+	if p == nil {
+		return
+	}
+	p.lines++
+	if p.lines%p.every != 0 {
+		return
+	}
+	now := p.now()
+	if !p.last.IsZero() && now.Sub(p.last) < p.interval {
+		return
+	}
+	p.last = now
+	fmt.Fprintf(p.w, "Read %d lines...\n", p.lines)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressReporter(t *testing.T) {
+	var buf bytes.Buffer
+	p := newProgressReporter(&buf, 10, time.Second)
+
+	// Every line takes 200ms, so the 10-line marks are 2s apart and the
+	// interval only holds back reports once they come faster than that.
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time {
+		return clock
+	}
+	for i := 0; i < 30; i++ {
+		clock = clock.Add(200 * time.Millisecond)
+		p.line()
+	}
+	if want := "Read 10 lines...\nRead 20 lines...\nRead 30 lines...\n"; buf.String() != want {
+		t.Errorf("progress = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	p.interval = 5 * time.Second
+	for i := 0; i < 60; i++ {
+		clock = clock.Add(200 * time.Millisecond)
+		p.line()
+	}
+	if want := "Read 60 lines...\nRead 90 lines...\n"; buf.String() != want {
+		t.Errorf("throttled progress = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestParseIPsFromReaderProgress(t *testing.T) {
+	var stderr bytes.Buffer
+	input := strings.Repeat("192.168.1.1\n", 25)
+	opts := parseOptions{stderr: &stderr, progress: newProgressReporter(&stderr, 10, 0)}
+	if _, err := parseIPsFromReader(strings.NewReader(input), opts); err != nil {
+		t.Fatalf("parseIPsFromReader() error = %v", err)
+	}
+	if want := "Read 10 lines...\nRead 20 lines...\n"; stderr.String() != want {
+		t.Errorf("stderr = %q, want %q", stderr.String(), want)
+	}
+}
+
+func TestParseGroupsFromReaderProgressPerGroup(t *testing.T) {
+	var stderr bytes.Buffer
+	input := strings.Repeat("192.168.1.1\n", 15) + "\n" + strings.Repeat("10.0.0.1\n", 12)
+	opts := parseOptions{stderr: &stderr, progress: newProgressReporter(&stderr, 10, 0)}
+	if _, err := parseGroupsFromReader(strings.NewReader(input), opts); err != nil {
+		t.Fatalf("parseGroupsFromReader() error = %v", err)
+	}
+	if want := "Read 10 lines...\nRead 10 lines...\n"; stderr.String() != want {
+		t.Errorf("stderr = %q, want %q", stderr.String(), want)
+	}
+}
+
+func TestRunProgressQuietErrors(t *testing.T) {
+	input := strings.Repeat("192.168.1.1\n", 100000)
+	for _, tt := range []struct {
+		args       []string
+		wantStderr string
+	}{
+		{args: []string{"-progress"}, wantStderr: "Read 100000 lines...\n"},
+		{args: []string{"-progress", "-quiet-errors"}, wantStderr: ""},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(tt.args, strings.NewReader(input), &stdout, &stderr); code != ExitOK {
+			t.Fatalf("run(%q) = %d, want %d", tt.args, code, ExitOK)
+		}
+		if stderr.String() != tt.wantStderr {
+			t.Errorf("run(%q) stderr = %q, want %q", tt.args, stderr.String(), tt.wantStderr)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/bits"
+	"net"
+	"sort"
+	"strings"
+)
+
+// parseRange parses an inclusive IPv4 range written as "first-last".
+func parseRange(s string) (uint32, uint32, error) {
+	first, last, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("range %q must be written as first-last", s)
+	}
+	firstIP := net.ParseIP(strings.TrimSpace(first)).To4()
+	if firstIP == nil {
+		return 0, 0, fmt.Errorf("invalid IPv4 address %q in range", first)
+	}
+	lastIP := net.ParseIP(strings.TrimSpace(last)).To4()
+	if lastIP == nil {
+		return 0, 0, fmt.Errorf("invalid IPv4 address %q in range", last)
+	}
+
+	start, end := ipToUint32(firstIP), ipToUint32(lastIP)
+	if start > end {
+		return 0, 0, fmt.Errorf("range %q ends before it starts", s)
+	}
+	return start, end, nil
+}
+
+// countRangeBlocks returns the number of CIDR blocks in the smallest set that
+// covers exactly the inclusive range [start, end]. An aligned range needs a
+// single block.
+func countRangeBlocks(start, end uint32) int {
+	return len(rangeToCIDRs(start, end))
+}
+
+// rangeToCIDRs returns the smallest set of aligned blocks covering exactly the
+// inclusive range [start, end], sorted by address.
+func rangeToCIDRs(start, end uint32) []net.IPNet {
+	var blocks []net.IPNet
+	for {
+		// Take the largest block aligned on start that doesn't go past end.
+		size := uint64(1) << bits.TrailingZeros32(start)
+		for uint64(start)+size-1 > uint64(end) {
+			size >>= 1
+		}
+		prefixLen := 32 - (bits.Len64(size) - 1)
+		blocks = append(blocks, net.IPNet{IP: uint32ToIP(start), Mask: net.CIDRMask(prefixLen, 32)})
+
+		next := uint64(start) + size
+		if next > uint64(end) {
+			return blocks
+		}
+		start = uint32(next)
+	}
+}
+
+// mergeRanges coalesces overlapping and touching inclusive ranges, where
+// touching means that one range ends right before the next starts. The result
+// is sorted by start address.
+func mergeRanges(ranges [][2]uint32) [][2]uint32 {
+	sorted := make([][2]uint32, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i][0] < sorted[j][0]
+	})
+
+	var merged [][2]uint32
+	for _, r := range sorted {
+		if n := len(merged); n > 0 && uint64(r[0]) <= uint64(merged[n-1][1])+1 {
+			if r[1] > merged[n-1][1] {
+				merged[n-1][1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// readRanges reads one first-last IPv4 range per line. Blank lines are
+// ignored.
+func readRanges(reader io.Reader) ([][2]uint32, error) {
+	var ranges [][2]uint32
+	scanner := bufio.NewScanner(reader)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		start, end, err := parseRange(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		ranges = append(ranges, [2]uint32{start, end})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
+
+// VerifyCover checks that the IPv4 blocks cover exactly the given addresses:
+// every address is in one of the blocks, every address of the blocks is one
+// of them and no two blocks overlap. It returns an error describing the first
+// discrepancy, which makes it suitable for checking the output of -minimal.
+func VerifyCover(blocks []*net.IPNet, ips []net.IP) error {
+	want := make([][2]uint32, 0, len(ips))
+	for _, ip := range ips {
+		u, ok := ipv4ToUint32(ip)
+		if !ok {
+			return fmt.Errorf("only IPv4 addresses are supported, got %s", ip)
+		}
+		want = append(want, [2]uint32{u, u})
+	}
+	return verifyRanges(blocks, mergeRanges(want))
+}
+
+// verifyRanges is like VerifyCover for the addresses of the given ranges,
+// which must be sorted and merged as by mergeRanges.
+func verifyRanges(blocks []*net.IPNet, want [][2]uint32) error {
+	got := make([][2]uint32, 0, len(blocks))
+	var covered uint64
+	for _, block := range blocks {
+		start, ok := ipv4ToUint32(block.IP)
+		ones, bits := block.Mask.Size()
+		if !ok || bits != 32 {
+			return fmt.Errorf("only IPv4 blocks are supported, got %s", block)
+		}
+		size := uint64(1) << (32 - ones)
+		got = append(got, [2]uint32{start, uint32(uint64(start) + size - 1)})
+		covered += size
+	}
+	got = mergeRanges(got)
+
+	var merged uint64
+	for _, r := range got {
+		merged += uint64(r[1]-r[0]) + 1
+	}
+	if merged != covered {
+		return fmt.Errorf("blocks overlap")
+	}
+
+	extra := func(u uint32) error {
+		return fmt.Errorf("%s is covered but not an input address", uint32ToIP(u))
+	}
+	missing := func(u uint32) error {
+		return fmt.Errorf("%s is not covered by any block", uint32ToIP(u))
+	}
+	for i := range max(len(got), len(want)) {
+		switch {
+		case i >= len(got):
+			return missing(want[i][0])
+		case i >= len(want):
+			return extra(got[i][0])
+		case got[i][0] < want[i][0]:
+			return extra(got[i][0])
+		case want[i][0] < got[i][0]:
+			return missing(want[i][0])
+		case got[i][1] < want[i][1]:
+			return missing(got[i][1] + 1)
+		case want[i][1] < got[i][1]:
+			return extra(want[i][1] + 1)
+		}
+	}
+	return nil
+}
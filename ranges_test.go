@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantStart string
+		wantEnd   string
+		wantErr   bool
+	}{
+		{name: "valid", input: "192.168.1.0-192.168.1.255", wantStart: "192.168.1.0", wantEnd: "192.168.1.255"},
+		{name: "spaces", input: "10.0.0.1 - 10.0.0.9", wantStart: "10.0.0.1", wantEnd: "10.0.0.9"},
+		{name: "single address", input: "10.0.0.1-10.0.0.1", wantStart: "10.0.0.1", wantEnd: "10.0.0.1"},
+		{name: "missing dash", input: "10.0.0.1", wantErr: true},
+		{name: "invalid address", input: "10.0.0.1-10.0.0.300", wantErr: true},
+		{name: "IPv6", input: "2001:db8::1-2001:db8::2", wantErr: true},
+		{name: "reversed", input: "10.0.0.9-10.0.0.1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := parseRange(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := uint32ToIP(start).String(); got != tt.wantStart {
+				t.Errorf("parseRange() start = %v, want %v", got, tt.wantStart)
+			}
+			if got := uint32ToIP(end).String(); got != tt.wantEnd {
+				t.Errorf("parseRange() end = %v, want %v", got, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestCountRangeBlocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		start string
+		end   string
+		want  int
+	}{
+		{name: "aligned /24", start: "192.168.1.0", end: "192.168.1.255", want: 1},
+		{name: "single address", start: "192.168.1.7", end: "192.168.1.7", want: 1},
+		{name: "misaligned by one on each side", start: "192.168.1.1", end: "192.168.1.254", want: 14},
+		{name: "two /24s straddling a /23", start: "192.168.1.0", end: "192.168.2.255", want: 2},
+		{name: "whole address space", start: "0.0.0.0", end: "255.255.255.255", want: 1},
+		{name: "worst case", start: "0.0.0.1", end: "255.255.255.254", want: 62},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := ipToUint32(net.ParseIP(tt.start))
+			end := ipToUint32(net.ParseIP(tt.end))
+			if got := countRangeBlocks(start, end); got != tt.want {
+				t.Errorf("countRangeBlocks() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRangeToCIDRs(t *testing.T) {
+	tests := []struct {
+		name  string
+		start string
+		end   string
+		want  []string
+	}{
+		{name: "single address", start: "192.168.1.7", end: "192.168.1.7", want: []string{"192.168.1.7/32"}},
+		{name: "aligned /24", start: "192.168.1.0", end: "192.168.1.255", want: []string{"192.168.1.0/24"}},
+		{name: "whole address space", start: "0.0.0.0", end: "255.255.255.255", want: []string{"0.0.0.0/0"}},
+		{name: "first address", start: "0.0.0.0", end: "0.0.0.0", want: []string{"0.0.0.0/32"}},
+		{name: "last address", start: "255.255.255.255", end: "255.255.255.255", want: []string{"255.255.255.255/32"}},
+		{
+			name:  "misaligned start and end",
+			start: "10.0.0.1",
+			end:   "10.0.0.10",
+			want:  []string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/30", "10.0.0.8/31", "10.0.0.10/32"},
+		},
+		{
+			name:  "crossing 128.0.0.0",
+			start: "127.255.255.254",
+			end:   "128.0.0.1",
+			want:  []string{"127.255.255.254/31", "128.0.0.0/31"},
+		},
+		{
+			name:  "halves of the address space",
+			start: "0.0.0.0",
+			end:   "127.255.255.255",
+			want:  []string{"0.0.0.0/1"},
+		},
+		{
+			name:  "up to the last address",
+			start: "255.255.255.253",
+			end:   "255.255.255.255",
+			want:  []string{"255.255.255.253/32", "255.255.255.254/31"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := ipToUint32(net.ParseIP(tt.start))
+			end := ipToUint32(net.ParseIP(tt.end))
+			var got []string
+			for _, block := range rangeToCIDRs(start, end) {
+				got = append(got, block.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("rangeToCIDRs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeRanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges [][2]uint32
+		want   [][2]uint32
+	}{
+		{
+			name:   "overlapping",
+			ranges: [][2]uint32{{10, 20}, {15, 30}},
+			want:   [][2]uint32{{10, 30}},
+		},
+		{
+			name:   "nested",
+			ranges: [][2]uint32{{10, 40}, {15, 30}},
+			want:   [][2]uint32{{10, 40}},
+		},
+		{
+			name:   "touching",
+			ranges: [][2]uint32{{21, 30}, {10, 20}},
+			want:   [][2]uint32{{10, 30}},
+		},
+		{
+			name:   "disjoint",
+			ranges: [][2]uint32{{22, 30}, {10, 20}},
+			want:   [][2]uint32{{10, 20}, {22, 30}},
+		},
+		{
+			name:   "chain",
+			ranges: [][2]uint32{{1, 1}, {3, 3}, {2, 2}, {4, 9}, {11, 12}},
+			want:   [][2]uint32{{1, 9}, {11, 12}},
+		},
+		{
+			name:   "up to the last address",
+			ranges: [][2]uint32{{0xfffffff0, 0xffffffff}, {0, 0}, {0xffffff00, 0xfffffff5}},
+			want:   [][2]uint32{{0, 0}, {0xffffff00, 0xffffffff}},
+		},
+		{
+			name:   "empty",
+			ranges: nil,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeRanges(tt.ranges)
+			if len(got) != len(tt.want) {
+				t.Fatalf("mergeRanges() = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("mergeRanges()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyCover(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocks  []string
+		ips     []string
+		wantErr string
+	}{
+		{
+			name:   "exact cover",
+			blocks: []string{"10.0.0.0/31", "10.0.0.9/32"},
+			ips:    []string{"10.0.0.9", "10.0.0.1", "10.0.0.0"},
+		},
+		{
+			name:    "missing address",
+			blocks:  []string{"10.0.0.0/31"},
+			ips:     []string{"10.0.0.0", "10.0.0.1", "10.0.0.2"},
+			wantErr: "10.0.0.2 is not covered by any block",
+		},
+		{
+			name:    "extra address",
+			blocks:  []string{"10.0.0.0/30"},
+			ips:     []string{"10.0.0.0", "10.0.0.1", "10.0.0.3"},
+			wantErr: "10.0.0.2 is covered but not an input address",
+		},
+		{
+			name:    "extra block",
+			blocks:  []string{"10.0.0.0/32", "10.0.0.8/32"},
+			ips:     []string{"10.0.0.0"},
+			wantErr: "10.0.0.8 is covered but not an input address",
+		},
+		{
+			name:    "overlapping blocks",
+			blocks:  []string{"10.0.0.0/31", "10.0.0.1/32"},
+			ips:     []string{"10.0.0.0", "10.0.0.1"},
+			wantErr: "blocks overlap",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ips []net.IP
+			for _, s := range tt.ips {
+				ips = append(ips, net.ParseIP(s))
+			}
+			err := VerifyCover(mustParseCIDRs(tt.blocks...), ips)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("VerifyCover() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("VerifyCover() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	ranges := [][2]string{
+		{"192.168.1.7", "192.168.1.7"},
+		{"10.0.0.1", "10.0.0.10"},
+		{"127.255.255.254", "128.0.0.1"},
+		{"0.0.0.0", "255.255.255.255"},
+		{"255.255.255.253", "255.255.255.255"},
+	}
+	for _, r := range ranges {
+		start := ipToUint32(net.ParseIP(r[0]))
+		end := ipToUint32(net.ParseIP(r[1]))
+		var blocks []*net.IPNet
+		for _, block := range rangeToCIDRs(start, end) {
+			blocks = append(blocks, &block)
+		}
+		if err := verifyRanges(blocks, [][2]uint32{{start, end}}); err != nil {
+			t.Errorf("rangeToCIDRs(%s, %s) doesn't round-trip: %v", r[0], r[1], err)
+		}
+	}
+
+	inputs := [][]string{
+		{"192.168.1.1"},
+		{"192.168.1.0", "192.168.1.1", "192.168.1.2", "192.168.1.3", "192.168.1.9"},
+		{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.200", "10.0.0.3"},
+	}
+	for _, input := range inputs {
+		var ips []net.IP
+		for _, s := range input {
+			ips = append(ips, net.ParseIP(s))
+		}
+		blocks, err := minimalCIDRs(ips)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyCover(blocks, ips); err != nil {
+			t.Errorf("minimalCIDRs(%v) doesn't round-trip: %v", input, err)
+		}
+	}
+}
+
+func TestRunVerify(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("10.0.0.1\n10.0.0.2\n10.0.0.3\n10.0.0.9\n")
+	code := run([]string{"-minimal", "-verify"}, stdin, &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+	}
+	if want := "10.0.0.1/32\n10.0.0.2/31\n10.0.0.9/32\n"; stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"math/rand"
+	"net"
+	"time"
+)
+
+// randomSeed picks the seed of the random choices when -seed isn't given.
+// Tests replace it to make runs without -seed reproducible.
+var randomSeed = func() int64 { return time.Now().UnixNano() }
+
+// sampleIPs returns k of the given IPs chosen at random with reservoir
+// sampling, in the order they were picked. All the IPs are returned when
+// there are k or fewer. The block enclosing the sample is only an estimate:
+// it is never wider, but may be narrower, than the block enclosing all the IPs.
+func sampleIPs(ips []net.IP, k int, rng *rand.Rand) []net.IP {
+	if len(ips) <= k {
+		return ips
+	}
+	sample := make([]net.IP, k)
+	copy(sample, ips[:k])
+	for i := k; i < len(ips); i++ {
+		if j := rng.Intn(i + 1); j < k {
+			sample[j] = ips[i]
+		}
+	}
+	return sample
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSampleIPs(t *testing.T) {
+	var ips []net.IP
+	for i := range 1000 {
+		ips = append(ips, uint32ToIP(0x0a000000+uint32(i)))
+	}
+
+	tests := []struct {
+		name string
+		k    int
+		want int
+	}{
+		{name: "fewer IPs than k", k: 2000, want: 1000},
+		{name: "as many IPs as k", k: 1000, want: 1000},
+		{name: "more IPs than k", k: 10, want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sampleIPs(ips, tt.k, rand.New(rand.NewSource(1)))
+			if len(got) != tt.want {
+				t.Fatalf("sampleIPs() returned %d IPs, want %d", len(got), tt.want)
+			}
+			for _, ip := range got {
+				if !slices.ContainsFunc(ips, ip.Equal) {
+					t.Errorf("sampleIPs() returned %s, which isn't in the input", ip)
+				}
+			}
+		})
+	}
+}
+
+func TestSampleIPsIsDeterministic(t *testing.T) {
+	var ips []net.IP
+	for i := range 1000 {
+		ips = append(ips, uint32ToIP(0x0a000000+uint32(i)))
+	}
+
+	first := sampleIPs(ips, 10, rand.New(rand.NewSource(42)))
+	second := sampleIPs(ips, 10, rand.New(rand.NewSource(42)))
+	if !slices.EqualFunc(first, second, net.IP.Equal) {
+		t.Errorf("sampleIPs() with the same seed = %v, then %v", first, second)
+	}
+}
+
+func TestRunSample(t *testing.T) {
+	var input strings.Builder
+	for i := range 1000 {
+		fmt.Fprintln(&input, uint32ToIP(0x0a000000+uint32(i)))
+	}
+
+	var outputs []string
+	for range 2 {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-sample", "10", "-seed", "42"}, strings.NewReader(input.String()), &stdout, &stderr)
+		if code != ExitOK {
+			t.Fatalf("run() = %d, want %d (stderr: %q)", code, ExitOK, stderr.String())
+		}
+		wantStderr := "Estimating from a sample of 10 of 1000 IPs: the true block may be wider.\n"
+		if stderr.String() != wantStderr {
+			t.Errorf("run() stderr = %q, want %q", stderr.String(), wantStderr)
+		}
+		outputs = append(outputs, stdout.String())
+	}
+	if outputs[0] != outputs[1] {
+		t.Errorf("run() with the same -seed printed %q, then %q", outputs[0], outputs[1])
+	}
+}
+
+func TestRunRandomSeed(t *testing.T) {
+	orig := randomSeed
+	randomSeed = func() int64 { return 7 }
+	defer func() { randomSeed = orig }()
+
+	var input strings.Builder
+	for i := range 1000 {
+		fmt.Fprintln(&input, uint32ToIP(0x0a000000+uint32(i)))
+	}
+
+	var outputs []string
+	for _, args := range [][]string{{"-sample", "10", "-self-check"}, {"-sample", "10", "-self-check"}, {"-sample", "10", "-self-check", "-seed", "7"}} {
+		var stdout, stderr bytes.Buffer
+		code := run(args, strings.NewReader(input.String()), &stdout, &stderr)
+		if code != ExitOK {
+			t.Fatalf("run(%q) = %d, want %d (stderr: %q)", args, code, ExitOK, stderr.String())
+		}
+		outputs = append(outputs, stdout.String())
+	}
+	if outputs[0] != outputs[1] || outputs[1] != outputs[2] {
+		t.Errorf("run() with the same seed printed %q", outputs)
+	}
+}
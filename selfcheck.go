@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"slices"
+)
+
+// aggregateFunc turns a group of IPs into their enclosing block and the blocks
+// printed in its place.
+type aggregateFunc func(ips []net.IP) (*net.IPNet, []*net.IPNet, error)
+
+// checkOrderIndependent aggregates a shuffled copy of ips and returns an error
+// if the resulting blocks differ from want, which were computed from ips in
+// their input order.
+func checkOrderIndependent(ips []net.IP, want []*net.IPNet, aggregate aggregateFunc, rng *rand.Rand) error {
+	shuffled := slices.Clone(ips)
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	_, got, err := aggregate(shuffled)
+	if err != nil {
+		return fmt.Errorf("aggregating the shuffled input: %v", err)
+	}
+	if !slices.EqualFunc(got, want, func(a, b *net.IPNet) bool { return a.String() == b.String() }) {
+		return fmt.Errorf("shuffled input gave %v instead of %v", got, want)
+	}
+	return nil
+}
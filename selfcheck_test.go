@@ -0,0 +1,47 @@
+package main
+
+import (
+	"math/rand"
+	"net"
+	"testing"
+)
+
+func TestCheckOrderIndependent(t *testing.T) {
+	var ips []net.IP
+	for _, s := range []string{"10.0.0.9", "10.0.0.1", "10.0.3.200", "10.0.2.4", "10.0.1.1"} {
+		ips = append(ips, net.ParseIP(s))
+	}
+
+	correct := func(ips []net.IP) (*net.IPNet, []*net.IPNet, error) {
+		ipnet, err := calculateIPNet(ips)
+		return ipnet, []*net.IPNet{ipnet}, err
+	}
+	// orderDependent wrongly assumes its input is sorted and only looks at
+	// the first and last IPs.
+	orderDependent := func(ips []net.IP) (*net.IPNet, []*net.IPNet, error) {
+		ipnet, err := calculateIPNet([]net.IP{ips[0], ips[len(ips)-1]})
+		return ipnet, []*net.IPNet{ipnet}, err
+	}
+
+	tests := []struct {
+		name      string
+		aggregate aggregateFunc
+		wantErr   bool
+	}{
+		{name: "correct aggregation", aggregate: correct},
+		{name: "order-dependent aggregation", aggregate: orderDependent, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, want, err := tt.aggregate(ips)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = checkOrderIndependent(ips, want, tt.aggregate, rand.New(rand.NewSource(1)))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkOrderIndependent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// maxSplitBlocks caps the number of blocks a split may produce.
+const maxSplitBlocks = 1 << 16
+
+// splitBlocks splits the IPv4 blocks shorter than /prefix into their /prefix
+// subnets. Blocks already /prefix or longer are kept as they are.
+func splitBlocks(blocks []*net.IPNet, prefix int) ([]*net.IPNet, error) {
+	var split []*net.IPNet
+	for _, block := range blocks {
+		ones, bits := block.Mask.Size()
+		if bits != 32 {
+			return nil, fmt.Errorf("only IPv4 blocks can be split, got %s", block)
+		}
+		if ones >= prefix {
+			split = append(split, block)
+			continue
+		}
+		if prefix-ones > 16 || len(split)+1<<(prefix-ones) > maxSplitBlocks {
+			return nil, fmt.Errorf("splitting %s into /%d blocks would produce more than %d blocks", block, prefix, maxSplitBlocks)
+		}
+		pending := []*net.IPNet{block}
+		for len(pending) > 0 {
+			b := pending[0]
+			pending = pending[1:]
+			if ones, _ := b.Mask.Size(); ones >= prefix {
+				split = append(split, b)
+				continue
+			}
+			lower, upper := splitCIDR(b)
+			pending = append(pending, lower, upper)
+		}
+	}
+	return split, nil
+}
+
+// clampPrefixes makes every IPv4 block fall between /minPrefix and /maxPrefix
+// by splitting the ones shorter than /minPrefix. It returns an error when a
+// block is longer than /maxPrefix, as joining it with its neighbours would
+// cover addresses it doesn't hold.
+func clampPrefixes(blocks []*net.IPNet, minPrefix, maxPrefix int) ([]*net.IPNet, error) {
+	if minPrefix > maxPrefix {
+		return nil, fmt.Errorf("no prefix is both /%d or longer and /%d or shorter", minPrefix, maxPrefix)
+	}
+	for _, block := range blocks {
+		if ones, _ := block.Mask.Size(); ones > maxPrefix {
+			return nil, fmt.Errorf("%s is longer than /%d", block, maxPrefix)
+		}
+	}
+	return splitBlocks(blocks, minPrefix)
+}
+
+// readLabels reads the names given to split subnets, one per line. Blank lines
+// are skipped.
+func readLabels(reader io.Reader) ([]string, error) {
+	var labels []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if label := strings.TrimSpace(scanner.Text()); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// loadLabels reads the labels stored in the file at path.
+func loadLabels(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readLabels(f)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitBlocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocks  []string
+		prefix  int
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "/24 into /26",
+			blocks: []string{"192.168.1.0/24"},
+			prefix: 26,
+			want:   []string{"192.168.1.0/26", "192.168.1.64/26", "192.168.1.128/26", "192.168.1.192/26"},
+		},
+		{
+			name:   "longer blocks are kept",
+			blocks: []string{"10.0.0.0/31", "10.0.1.0/25"},
+			prefix: 26,
+			want:   []string{"10.0.0.0/31", "10.0.1.0/26", "10.0.1.64/26"},
+		},
+		{
+			name:    "too many blocks",
+			blocks:  []string{"10.0.0.0/8"},
+			prefix:  32,
+			wantErr: true,
+		},
+		{
+			name:    "IPv6",
+			blocks:  []string{"2001:db8::/32"},
+			prefix:  34,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, err := splitBlocks(mustParseCIDRs(tt.blocks...), tt.prefix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitBlocks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, b := range blocks {
+				got = append(got, b.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("splitBlocks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClampPrefixes(t *testing.T) {
+	tests := []struct {
+		name      string
+		blocks    []string
+		minPrefix int
+		maxPrefix int
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:      "within the window",
+			blocks:    []string{"10.0.0.0/25", "10.0.1.0/24"},
+			minPrefix: 24,
+			maxPrefix: 26,
+			want:      []string{"10.0.0.0/25", "10.0.1.0/24"},
+		},
+		{
+			name:      "broad blocks are split",
+			blocks:    []string{"10.0.0.0/23"},
+			minPrefix: 24,
+			maxPrefix: 26,
+			want:      []string{"10.0.0.0/24", "10.0.1.0/24"},
+		},
+		{
+			name:      "block too small",
+			blocks:    []string{"10.0.0.0/24", "10.0.1.0/28"},
+			minPrefix: 24,
+			maxPrefix: 26,
+			wantErr:   true,
+		},
+		{
+			name:      "empty window",
+			blocks:    []string{"10.0.0.0/24"},
+			minPrefix: 26,
+			maxPrefix: 24,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, err := clampPrefixes(mustParseCIDRs(tt.blocks...), tt.minPrefix, tt.maxPrefix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("clampPrefixes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, b := range blocks {
+				got = append(got, b.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("clampPrefixes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadLabels(t *testing.T) {
+	got, err := readLabels(strings.NewReader("subnet-a\n\n  subnet-b  \nsubnet-c\n"))
+	if err != nil {
+		t.Fatalf("readLabels() error = %v", err)
+	}
+	if want := []string{"subnet-a", "subnet-b", "subnet-c"}; !slices.Equal(got, want) {
+		t.Errorf("readLabels() = %v, want %v", got, want)
+	}
+}
+
+func TestRunSplitLabels(t *testing.T) {
+	tests := []struct {
+		name       string
+		labels     string
+		args       []string
+		wantCode   int
+		wantStdout string
+	}{
+		{
+			name:       "as many labels as subnets",
+			labels:     "subnet-a\nsubnet-b\nsubnet-c\nsubnet-d\n",
+			wantCode:   ExitOK,
+			wantStdout: "subnet-a: 192.168.1.0/26\nsubnet-b: 192.168.1.64/26\nsubnet-c: 192.168.1.128/26\nsubnet-d: 192.168.1.192/26\n",
+		},
+		{
+			name:     "fewer labels than subnets",
+			labels:   "subnet-a\nsubnet-b\n",
+			wantCode: ExitFailure,
+		},
+		{
+			name:       "fewer labels than subnets with -allow-unlabeled",
+			labels:     "subnet-a\nsubnet-b\n",
+			args:       []string{"-allow-unlabeled"},
+			wantCode:   ExitOK,
+			wantStdout: "subnet-a: 192.168.1.0/26\nsubnet-b: 192.168.1.64/26\n192.168.1.128/26\n192.168.1.192/26\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "labels.txt")
+			if err := os.WriteFile(path, []byte(tt.labels), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"-split", "26", "-labels", path}, tt.args...)
+			code := run(args, strings.NewReader("192.168.1.1\n192.168.1.254\n"), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("run() = %d, want %d (stderr: %q)", code, tt.wantCode, stderr.String())
+			}
+			if tt.wantCode == ExitOK && stdout.String() != tt.wantStdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+)
+
+// spreadPrefix is the size of the sub-blocks counted by -spread.
+const spreadPrefix = 28
+
+// addressSpread describes how IPv4 addresses are distributed within the block
+// enclosing them.
+type addressSpread struct {
+	subPrefix int     // Prefix length of the sub-blocks counted.
+	touched   int     // Number of distinct sub-blocks holding an address.
+	total     int     // Number of sub-blocks in the block.
+	stddev    float64 // Standard deviation of the positions within the block.
+}
+
+// String formats the spread as printed by -spread.
+func (s addressSpread) String() string {
+	return fmt.Sprintf("spread: %d of %d /%d sub-blocks touched, standard deviation of positions %.1f",
+		s.touched, s.total, s.subPrefix, s.stddev)
+}
+
+// spreadOf measures the spread of the IPv4 addresses ips within block, using
+// /spreadPrefix sub-blocks (or the block itself when it is smaller).
+func spreadOf(block *net.IPNet, ips []net.IP) (addressSpread, error) {
+	ones, bits := block.Mask.Size()
+	if bits != 32 {
+		return addressSpread{}, fmt.Errorf("-spread only supports IPv4 blocks")
+	}
+	subPrefix := max(spreadPrefix, ones)
+	start := ipToUint32(block.IP)
+
+	touched := make(map[uint32]bool)
+	var positions []float64
+	for _, ip := range ips {
+		if ip.To4() == nil || !block.Contains(ip) {
+			continue
+		}
+		pos := ipToUint32(ip) - start
+		touched[pos>>(32-subPrefix)] = true
+		positions = append(positions, float64(pos))
+	}
+
+	var mean, variance float64
+	for _, p := range positions {
+		mean += p
+	}
+	if len(positions) > 0 {
+		mean /= float64(len(positions))
+		for _, p := range positions {
+			variance += (p - mean) * (p - mean)
+		}
+		variance /= float64(len(positions))
+	}
+
+	return addressSpread{
+		subPrefix: subPrefix,
+		touched:   len(touched),
+		total:     1 << (subPrefix - ones),
+		stddev:    math.Sqrt(variance),
+	}, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestSpreadOf(t *testing.T) {
+	tests := []struct {
+		name string
+		cidr string
+		ips  []string
+		want addressSpread
+	}{
+		{
+			name: "concentrated",
+			cidr: "10.0.0.0/24",
+			ips:  []string{"10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.0.255"},
+			want: addressSpread{subPrefix: 28, touched: 2, total: 16, stddev: 109.99},
+		},
+		{
+			name: "scattered",
+			cidr: "10.0.0.0/24",
+			ips:  []string{"10.0.0.0", "10.0.0.64", "10.0.0.128", "10.0.0.192"},
+			want: addressSpread{subPrefix: 28, touched: 4, total: 16, stddev: 71.55},
+		},
+		{
+			name: "block smaller than a sub-block",
+			cidr: "10.0.0.0/30",
+			ips:  []string{"10.0.0.1", "10.0.0.3"},
+			want: addressSpread{subPrefix: 30, touched: 1, total: 1, stddev: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, block, _ := net.ParseCIDR(tt.cidr)
+			var ips []net.IP
+			for _, s := range tt.ips {
+				ips = append(ips, net.ParseIP(s))
+			}
+			got, err := spreadOf(block, ips)
+			if err != nil {
+				t.Fatalf("spreadOf() error = %v", err)
+			}
+			if got.subPrefix != tt.want.subPrefix || got.touched != tt.want.touched || got.total != tt.want.total {
+				t.Errorf("spreadOf() = %+v, want %+v", got, tt.want)
+			}
+			if diff := got.stddev - tt.want.stddev; diff < -0.01 || diff > 0.01 {
+				t.Errorf("spreadOf() stddev = %.2f, want %.2f", got.stddev, tt.want.stddev)
+			}
+		})
+	}
+}
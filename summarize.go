@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// cidrEntry is a block read from a route file along with the comments that
+// were attached to it.
+type cidrEntry struct {
+	ipnet    *net.IPNet
+	comments []string
+}
+
+// readCIDRFile reads a route file with one IPv4 CIDR per line. Blank lines are
+// ignored and "#" starts a comment. Comment lines are attached to the CIDR that
+// follows them and trailing comments to the CIDR on their line; comments after
+// the last CIDR are returned separately.
+func readCIDRFile(reader io.Reader) ([]cidrEntry, []string, error) {
+	var entries []cidrEntry
+	var pending []string
+	scanner := bufio.NewScanner(reader)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line, comment, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if comment != "" {
+				pending = append(pending, "#"+comment)
+			}
+			continue
+		}
+
+		_, ipnet, err := parseCIDR(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		if ipnet.IP.To4() == nil {
+			return nil, nil, fmt.Errorf("line %d: only IPv4 CIDRs are supported: %s", lineNum, line)
+		}
+		if comment != "" {
+			pending = append(pending, "#"+comment)
+		}
+		entries = append(entries, cidrEntry{ipnet: ipnet, comments: pending})
+		pending = nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return entries, pending, nil
+}
+
+// summarizeCIDRs merges the entries into the fewest blocks and renders them one
+// per line. With keepComments, each block is preceded by the comments of the
+// entries it covers, and the trailing comments are kept at the end.
+func summarizeCIDRs(entries []cidrEntry, trailing []string, keepComments bool) string {
+	nets := make([]*net.IPNet, len(entries))
+	for i, e := range entries {
+		nets[i] = e.ipnet
+	}
+
+	var b strings.Builder
+	for _, block := range mergeCIDRs(nets) {
+		if keepComments {
+			for _, e := range entries {
+				if block.Contains(e.ipnet.IP) {
+					for _, c := range e.comments {
+						fmt.Fprintln(&b, c)
+					}
+				}
+			}
+		}
+		fmt.Fprintln(&b, block)
+	}
+	if keepComments {
+		for _, c := range trailing {
+			fmt.Fprintln(&b, c)
+		}
+	}
+	return b.String()
+}
+
+// summarizeOptions controls how summarizeFile processes a route file.
+type summarizeOptions struct {
+	inPlace      bool // Rewrite the file and keep the original as path.bak.
+	keepComments bool // Keep comments next to the blocks they describe.
+
+	// duplicates, when set, receives a line for each CIDR listed more than
+	// once.
+	duplicates io.Writer
+}
+
+// duplicateCIDRs returns the CIDRs listed more than once in entries, in the
+// order of their first occurrence, along with the number of times each is
+// listed. CIDRs are compared in their canonical form, so 10.0.0.5/24
+// duplicates 10.0.0.0/24.
+func duplicateCIDRs(entries []cidrEntry) ([]string, map[string]int) {
+	counts := make(map[string]int)
+	var dups []string
+	for _, e := range entries {
+		key := e.ipnet.String()
+		counts[key]++
+		if counts[key] == 2 {
+			dups = append(dups, key)
+		}
+	}
+	return dups, counts
+}
+
+// summarizeFile merges the CIDRs listed in the file at path. The result is
+// printed to stdout, or, when opts.inPlace is set, written back to the file
+// after saving the original content to path.bak.
+func summarizeFile(path string, opts summarizeOptions, stdout io.Writer) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	entries, trailing, err := readCIDRFile(strings.NewReader(string(content)))
+	if err != nil {
+		return err
+	}
+	if opts.duplicates != nil {
+		dups, counts := duplicateCIDRs(entries)
+		for _, cidr := range dups {
+			fmt.Fprintf(opts.duplicates, "Duplicate CIDR %s listed %d times\n", cidr, counts[cidr])
+		}
+	}
+	summary := summarizeCIDRs(entries, trailing, opts.keepComments)
+
+	if !opts.inPlace {
+		_, err := io.WriteString(stdout, summary)
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".bak", content, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(summary), info.Mode().Perm())
+}
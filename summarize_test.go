@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeCIDRs(t *testing.T) {
+	input := `# office
+192.168.1.0/25
+192.168.1.128/25 # lab
+
+10.0.0.0/8
+10.1.0.0/16 # nested
+# end of file
+`
+
+	tests := []struct {
+		name         string
+		keepComments bool
+		want         string
+	}{
+		{
+			name: "comments dropped",
+			want: "10.0.0.0/8\n192.168.1.0/24\n",
+		},
+		{
+			name:         "comments kept",
+			keepComments: true,
+			want:         "# nested\n10.0.0.0/8\n# office\n# lab\n192.168.1.0/24\n# end of file\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, trailing, err := readCIDRFile(strings.NewReader(input))
+			if err != nil {
+				t.Fatalf("readCIDRFile() error = %v", err)
+			}
+			if got := summarizeCIDRs(entries, trailing, tt.keepComments); got != tt.want {
+				t.Errorf("summarizeCIDRs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCIDRFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "invalid CIDR",
+			input:   "10.0.0.0/8\nnot-a-cidr\n",
+			wantErr: "line 2:",
+		},
+		{
+			name:    "bad prefix",
+			input:   "10.0.0.0/8\n\n# comment\n10.1.0.0/33\n",
+			wantErr: "line 4: prefix /33 out of range for IPv4",
+		},
+		{
+			name:    "bad address",
+			input:   "10.0.0.300/24\n",
+			wantErr: `line 1: invalid address "10.0.0.300"`,
+		},
+		{
+			name:    "IPv6 CIDR",
+			input:   "2001:db8::/32\n",
+			wantErr: "line 1: only IPv4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := readCIDRFile(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("readCIDRFile() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSummarizeFileInPlace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.txt")
+	original := "192.168.1.0/25\n# upper half\n192.168.1.128/25\n"
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := summarizeFile(path, summarizeOptions{inPlace: true, keepComments: true}, nil); err != nil {
+		t.Fatalf("summarizeFile() error = %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# upper half\n192.168.1.0/24\n"; string(got) != want {
+		t.Errorf("rewritten file = %q, want %q", got, want)
+	}
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(backup) != original {
+		t.Errorf("backup file = %q, want %q", backup, original)
+	}
+}
+
+func TestSummarizeFileReportsDuplicates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.txt")
+	content := "10.0.0.0/24\n192.168.1.0/24\n10.0.0.5/24\n10.0.1.0/24\n192.168.1.0/24\n10.0.0.0/24\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, report bytes.Buffer
+	if err := summarizeFile(path, summarizeOptions{duplicates: &report}, &stdout); err != nil {
+		t.Fatalf("summarizeFile() error = %v", err)
+	}
+
+	wantReport := "Duplicate CIDR 10.0.0.0/24 listed 3 times\nDuplicate CIDR 192.168.1.0/24 listed 2 times\n"
+	if report.String() != wantReport {
+		t.Errorf("duplicates report = %q, want %q", report.String(), wantReport)
+	}
+	if want := "10.0.0.0/23\n192.168.1.0/24\n"; stdout.String() != want {
+		t.Errorf("summarizeFile() output = %q, want %q", stdout.String(), want)
+	}
+}
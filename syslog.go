@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+)
+
+// syslogWriter is the part of *syslog.Writer used by -syslog. Tests replace
+// dialSyslog to capture the messages instead.
+type syslogWriter interface {
+	Info(msg string) error
+	Err(msg string) error
+	Close() error
+}
+
+// dialSyslog connects to the local syslog daemon, tagging the messages with
+// tag.
+var dialSyslog = dialLocalSyslog
+
+// syslogLines is an io.Writer sending each line written to it as a syslog
+// message through send.
+type syslogLines struct {
+	send func(msg string) error
+	buf  bytes.Buffer
+}
+
+func (s *syslogLines) Write(p []byte) (int, error) {
+	s.buf.Write(p)
+	for {
+		line, err := s.buf.ReadString('\n')
+		if err != nil {
+			// No newline yet: keep the partial line for the next write.
+			s.buf.WriteString(line)
+			return len(p), nil
+		}
+		if err := s.send(strings.TrimSuffix(line, "\n")); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush sends the last line, when the output doesn't end with a newline.
+func (s *syslogLines) Flush() error {
+	if s.buf.Len() == 0 {
+		return nil
+	}
+	line := s.buf.String()
+	s.buf.Reset()
+	return s.send(line)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeSyslog records the messages sent to syslog.
+type fakeSyslog struct {
+	tag    string
+	info   []string
+	err    []string
+	closed bool
+}
+
+func (f *fakeSyslog) Info(msg string) error { f.info = append(f.info, msg); return nil }
+func (f *fakeSyslog) Err(msg string) error  { f.err = append(f.err, msg); return nil }
+func (f *fakeSyslog) Close() error          { f.closed = true; return nil }
+
+func useFakeSyslog(t *testing.T) *fakeSyslog {
+	t.Helper()
+	fake := &fakeSyslog{}
+	orig := dialSyslog
+	dialSyslog = func(tag string) (syslogWriter, error) {
+		fake.tag = tag
+		return fake, nil
+	}
+	t.Cleanup(func() { dialSyslog = orig })
+	return fake
+}
+
+func TestRunSyslog(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout string
+		wantTag    string
+		wantInfo   []string
+		wantErr    []string
+	}{
+		{
+			name:       "results",
+			args:       []string{"-syslog"},
+			stdin:      "192.168.1.1\n192.168.1.2\n",
+			wantCode:   ExitOK,
+			wantStdout: "192.168.1.0/30\n",
+			wantTag:    "cidrcalc",
+			wantInfo:   []string{"192.168.1.0/30"},
+		},
+		{
+			name:       "custom tag without newline",
+			args:       []string{"-syslog", "-syslog-tag", "firewall", "-no-newline"},
+			stdin:      "10.0.0.1\n",
+			wantCode:   ExitOK,
+			wantStdout: "10.0.0.1/32",
+			wantTag:    "firewall",
+			wantInfo:   []string{"10.0.0.1/32"},
+		},
+		{
+			name:     "errors",
+			args:     []string{"-syslog", "-quiet-errors"},
+			stdin:    "invalid\n",
+			wantCode: ExitNoInput,
+			wantTag:  "cidrcalc",
+			wantErr:  []string{"No valid IPs provided."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useFakeSyslog(t)
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Errorf("run() = %d, want %d (stderr: %q)", code, tt.wantCode, stderr.String())
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if fake.tag != tt.wantTag {
+				t.Errorf("syslog tag = %q, want %q", fake.tag, tt.wantTag)
+			}
+			if !reflect.DeepEqual(fake.info, tt.wantInfo) {
+				t.Errorf("syslog info = %q, want %q", fake.info, tt.wantInfo)
+			}
+			if !reflect.DeepEqual(fake.err, tt.wantErr) {
+				t.Errorf("syslog err = %q, want %q", fake.err, tt.wantErr)
+			}
+			if !fake.closed {
+				t.Error("syslog writer not closed")
+			}
+		})
+	}
+}
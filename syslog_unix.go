@@ -0,0 +1,9 @@
+//go:build !windows && !plan9
+
+package main
+
+import "log/syslog"
+
+func dialLocalSyslog(tag string) (syslogWriter, error) {
+	return syslog.New(syslog.LOG_INFO|syslog.LOG_USER, tag)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"time"
+)
+
+// errInputTimeout is returned by readers from newTimeoutReader when no line
+// arrives in time.
+var errInputTimeout = errors.New("no input received in time")
+
+// newTimeoutReader returns a reader over the lines of r that fails with
+// errInputTimeout as soon as r goes longer than timeout without providing a
+// new line. It keeps interactive sessions from blocking forever on an empty
+// stdin.
+//
+// On timeout r is closed when it implements io.Closer, which unblocks the
+// scanning goroutine. Otherwise that goroutine stays blocked in Read until r
+// returns, so the reader is only meant for stdin, once per run.
+func newTimeoutReader(r io.Reader, timeout time.Duration) io.Reader {
+	pr, pw := io.Pipe()
+	lines := make(chan string)
+	errc := make(chan error, 1)
+	done := make(chan struct{})
+
+	go func() {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text() + "\n":
+			case <-done:
+				return
+			}
+		}
+		errc <- scanner.Err()
+		close(lines)
+	}()
+
+	go func() {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		defer close(done)
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					pw.CloseWithError(<-errc)
+					return
+				}
+				if _, err := io.WriteString(pw, line); err != nil {
+					return
+				}
+				timer.Reset(timeout)
+			case <-timer.C:
+				if c, ok := r.(io.Closer); ok {
+					c.Close()
+				}
+				pw.CloseWithError(errInputTimeout)
+				return
+			}
+		}
+	}()
+
+	return pr
+}
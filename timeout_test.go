@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowReader returns its lines one at a time, waiting delay before each.
+type slowReader struct {
+	lines []string
+	delay time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if len(r.lines) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.delay)
+	n := copy(p, r.lines[0]+"\n")
+	r.lines = r.lines[1:]
+	return n, nil
+}
+
+func TestNewTimeoutReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		reader  io.Reader
+		want    []string
+		wantErr error
+	}{
+		{
+			name:   "lines arrive in time",
+			reader: &slowReader{lines: []string{"192.168.1.1", "192.168.1.2"}, delay: time.Millisecond},
+			want:   []string{"192.168.1.1", "192.168.1.2"},
+		},
+		{
+			name:    "no line at all",
+			reader:  &slowReader{lines: []string{"192.168.1.1"}, delay: time.Hour},
+			wantErr: errInputTimeout,
+		},
+		{
+			name:    "stalls after the first line",
+			reader:  io.MultiReader(strings.NewReader("192.168.1.1\n"), &slowReader{lines: []string{"192.168.1.2"}, delay: time.Hour}),
+			wantErr: errInputTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ips, err := parseIPsFromReader(newTimeoutReader(tt.reader, 200*time.Millisecond), parseOptions{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseIPsFromReader() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(ips) != len(tt.want) {
+				t.Fatalf("parseIPsFromReader() = %v, want %v", ips, tt.want)
+			}
+			for i := range tt.want {
+				if ips[i].String() != tt.want[i] {
+					t.Errorf("parseIPsFromReader() IP[%d] = %v, want %v", i, ips[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRunStdinTimeout(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := &slowReader{lines: []string{"192.168.1.1"}, delay: time.Hour}
+	code := run([]string{"-stdin-timeout", "50ms"}, stdin, &stdout, &stderr)
+	if code != ExitNoInput {
+		t.Errorf("run() = %d, want %d", code, ExitNoInput)
+	}
+	if !strings.Contains(stderr.String(), errInputTimeout.Error()) {
+		t.Errorf("run() stderr = %q, want it to mention the timeout", stderr.String())
+	}
+}
+
+func TestNewTimeoutReaderClosesReader(t *testing.T) {
+	r, w := io.Pipe()
+	if _, err := io.ReadAll(newTimeoutReader(r, 50*time.Millisecond)); !errors.Is(err, errInputTimeout) {
+		t.Fatalf("ReadAll() error = %v, want %v", err, errInputTimeout)
+	}
+	if _, err := w.Write([]byte("192.168.1.1\n")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Write() after timeout error = %v, want %v", err, io.ErrClosedPipe)
+	}
+}
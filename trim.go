@@ -0,0 +1,19 @@
+package main
+
+import (
+	"net"
+	"slices"
+)
+
+// trimOutliers drops the lowest and the highest percentile% of ips, by
+// address, and returns the remaining ones along with the number of dropped
+// ones. percentile must be lower than 50.
+func trimOutliers(ips []net.IP, percentile float64) ([]net.IP, int) {
+	n := int(float64(len(ips)) * percentile / 100)
+	if n == 0 {
+		return ips, 0
+	}
+	sorted := slices.Clone(ips)
+	sortByIP(sorted, func(ip net.IP) net.IP { return ip })
+	return sorted[n : len(sorted)-n], 2 * n
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestTrimOutliers(t *testing.T) {
+	var ips []net.IP
+	for _, s := range []string{"10.0.0.5", "10.0.0.1", "10.0.0.3", "192.168.1.1", "10.0.0.9", "10.0.0.2", "10.0.0.7", "10.0.0.8", "10.0.0.4", "10.0.0.6"} {
+		ips = append(ips, net.ParseIP(s))
+	}
+
+	tests := []struct {
+		name        string
+		percentile  float64
+		wantTrimmed int
+		wantCIDR    string
+	}{
+		{name: "no trimming", percentile: 0, wantTrimmed: 0, wantCIDR: "0.0.0.0/0"},
+		{name: "too few IPs to trim", percentile: 5, wantTrimmed: 0, wantCIDR: "0.0.0.0/0"},
+		{name: "single outlier", percentile: 10, wantTrimmed: 2, wantCIDR: "10.0.0.0/28"},
+		{name: "several per end", percentile: 30, wantTrimmed: 6, wantCIDR: "10.0.0.4/30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, trimmed := trimOutliers(ips, tt.percentile)
+			if trimmed != tt.wantTrimmed || len(kept) != len(ips)-trimmed {
+				t.Errorf("trimOutliers() kept %d and trimmed %d, want %d trimmed", len(kept), trimmed, tt.wantTrimmed)
+			}
+			got, err := calculateCIDR(kept)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.wantCIDR {
+				t.Errorf("block of trimmed IPs = %s, want %s", got, tt.wantCIDR)
+			}
+		})
+	}
+}
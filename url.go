@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// fetchIPs downloads the newline-delimited list of IPs served at url and
+// parses it like stdin. A zero timeout means no timeout.
+func fetchIPs(url string, timeout time.Duration, opts parseOptions) ([]net.IP, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return parseIPsFromReader(resp.Body, opts)
+}
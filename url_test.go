@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetchIPs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/list.txt":
+			fmt.Fprint(w, "192.168.1.1\n192.168.1.2\ninvalid\n")
+		case "/slow.txt":
+			time.Sleep(200 * time.Millisecond)
+			fmt.Fprint(w, "192.168.1.1\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		timeout time.Duration
+		wantLen int
+		wantErr bool
+	}{
+		{name: "list", path: "/list.txt", wantLen: 2},
+		{name: "not found", path: "/missing.txt", wantErr: true},
+		{name: "timeout", path: "/slow.txt", timeout: 50 * time.Millisecond, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ips, err := fetchIPs(srv.URL+tt.path, tt.timeout, parseOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fetchIPs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(ips) != tt.wantLen {
+				t.Errorf("fetchIPs() returned %d IPs, want %d", len(ips), tt.wantLen)
+			}
+		})
+	}
+}
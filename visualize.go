@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// visualizeWidth is the number of cells of the bars drawn by -visualize.
+const visualizeWidth = 64
+
+// ANSI color codes used to draw the bars.
+const (
+	colorGreen = "\033[32m"
+	colorReset = "\033[0m"
+)
+
+// visualizeBlock draws a bar of at most width cells for an IPv4 block, each
+// cell standing for an equal share of its addresses: "#" when the share holds
+// at least one of the ips, "." when it is empty. With color, the "#" cells are
+// drawn in green.
+func visualizeBlock(block *net.IPNet, ips []net.IP, width int, color bool) (string, error) {
+	ones, bits := block.Mask.Size()
+	if bits != 32 {
+		return "", fmt.Errorf("-visualize only supports IPv4 blocks")
+	}
+	size := uint64(1) << (32 - ones)
+	cells := min(uint64(width), size)
+	perCell := size / cells
+
+	used := make([]bool, cells)
+	start := uint64(ipToUint32(block.IP))
+	for _, ip := range ips {
+		if ip.To4() == nil || !block.Contains(ip) {
+			continue
+		}
+		used[(uint64(ipToUint32(ip))-start)/perCell] = true
+	}
+
+	var bar strings.Builder
+	bar.WriteString("[")
+	for _, u := range used {
+		switch {
+		case u && color:
+			bar.WriteString(colorGreen + "#" + colorReset)
+		case u:
+			bar.WriteString("#")
+		default:
+			bar.WriteString(".")
+		}
+	}
+	bar.WriteString("]")
+	return bar.String(), nil
+}
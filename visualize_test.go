@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestVisualizeBlock(t *testing.T) {
+	tests := []struct {
+		name  string
+		cidr  string
+		ips   []string
+		width int
+		color bool
+		want  string
+	}{
+		{
+			name:  "sparse /26",
+			cidr:  "192.168.1.0/26",
+			ips:   []string{"192.168.1.0", "192.168.1.1", "192.168.1.63"},
+			width: 64,
+			want:  "[##" + strings.Repeat(".", 61) + "#]",
+		},
+		{
+			name:  "cells covering several addresses",
+			cidr:  "192.168.1.0/24",
+			ips:   []string{"192.168.1.5", "192.168.1.130"},
+			width: 8,
+			want:  "[#...#...]",
+		},
+		{
+			name:  "block smaller than the width",
+			cidr:  "10.0.0.0/30",
+			ips:   []string{"10.0.0.2"},
+			width: 64,
+			want:  "[..#.]",
+		},
+		{
+			name:  "color",
+			cidr:  "10.0.0.0/31",
+			ips:   []string{"10.0.0.0"},
+			width: 64,
+			color: true,
+			want:  "[\033[32m#\033[0m.]",
+		},
+		{
+			name:  "whole address space",
+			cidr:  "0.0.0.0/0",
+			ips:   []string{"255.255.255.255"},
+			width: 4,
+			want:  "[...#]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, block, _ := net.ParseCIDR(tt.cidr)
+			var ips []net.IP
+			for _, s := range tt.ips {
+				ips = append(ips, net.ParseIP(s))
+			}
+			got, err := visualizeBlock(block, ips, tt.width, tt.color)
+			if err != nil {
+				t.Fatalf("visualizeBlock() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("visualizeBlock() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"net"
+)
+
+// AWS only accepts IPv4 VPC CIDR blocks between these two sizes.
+const (
+	vpcMinPrefix = 16
+	vpcMaxPrefix = 28
+)
+
+// checkVPC returns an error explaining why block cannot be used as the CIDR
+// block of an AWS VPC, or nil if it can.
+func checkVPC(block *net.IPNet) error {
+	ones, bits := block.Mask.Size()
+	switch {
+	case bits != 32:
+		return fmt.Errorf("%s is not an IPv4 block, AWS assigns the IPv6 blocks of VPCs itself", block)
+	case ones < vpcMinPrefix:
+		return fmt.Errorf("%s is too broad for an AWS VPC: the prefix must be /%d or longer, consider several VPCs or -floor-prefix %d", block, vpcMinPrefix, vpcMinPrefix)
+	case ones > vpcMaxPrefix:
+		return fmt.Errorf("%s is too small for an AWS VPC: the prefix must be /%d or shorter, use a /%d holding it instead", block, vpcMaxPrefix, vpcMaxPrefix)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestCheckVPC(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidr    string
+		wantErr bool
+	}{
+		{name: "in range", cidr: "10.0.0.0/20"},
+		{name: "shortest allowed", cidr: "10.0.0.0/16"},
+		{name: "longest allowed", cidr: "10.0.0.0/28"},
+		{name: "too broad", cidr: "10.0.0.0/8", wantErr: true},
+		{name: "too small", cidr: "10.0.0.0/30", wantErr: true},
+		{name: "IPv6", cidr: "2001:db8::/56", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, block, err := net.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := checkVPC(block); (err != nil) != tt.wantErr {
+				t.Errorf("checkVPC() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// watchHostname resolves hostname right away and then on every tick, and
+// prints the enclosing block whenever it differs from the previous one. Each
+// change is logged to stderr with the addresses that appeared and disappeared
+// since the previous resolution. Lookup failures are reported through errorf
+// and the watch goes on. It returns when ticks is closed.
+func watchHostname(hostname string, ticks <-chan time.Time, stdout, stderr io.Writer, errorf func(format string, args ...any)) {
+	var prevIPs []net.IP
+	var prevCIDR string
+	for {
+		ips, err := resolveHostname(hostname)
+		if err != nil {
+			errorf("Error resolving hostname %s: %v\n", hostname, err)
+		} else if cidr, err := calculateCIDR(ips); err != nil {
+			errorf("Error calculating CIDR: %v\n", err)
+		} else {
+			if cidr != prevCIDR {
+				if prevCIDR != "" {
+					added, removed := diffIPs(prevIPs, ips)
+					fmt.Fprintf(stderr, "CIDR changed from %s to %s: added %v, removed %v\n", prevCIDR, cidr, added, removed)
+				}
+				fmt.Fprintln(stdout, cidr)
+				prevCIDR = cidr
+			}
+			prevIPs = ips
+		}
+
+		if _, ok := <-ticks; !ok {
+			return
+		}
+	}
+}
+
+// diffIPs returns the addresses of next that aren't in prev and those of prev
+// that aren't in next.
+func diffIPs(prev, next []net.IP) (added, removed []net.IP) {
+	inPrev := make(map[string]bool, len(prev))
+	for _, ip := range prev {
+		inPrev[ip.String()] = true
+	}
+	inNext := make(map[string]bool, len(next))
+	for _, ip := range next {
+		inNext[ip.String()] = true
+		if !inPrev[ip.String()] {
+			added = append(added, ip)
+		}
+	}
+	for _, ip := range prev {
+		if !inNext[ip.String()] {
+			removed = append(removed, ip)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchHostname(t *testing.T) {
+	responses := []struct {
+		ips []string
+		err error
+	}{
+		{ips: []string{"192.168.1.1", "192.168.1.2"}},
+		{ips: []string{"192.168.1.2", "192.168.1.1"}},
+		{ips: []string{"192.168.1.1", "192.168.1.200"}},
+		{err: errors.New("temporary failure")},
+		{ips: []string{"192.168.1.1", "192.168.1.200"}},
+		{ips: []string{"192.168.1.1"}},
+	}
+
+	orig := lookupIP
+	defer func() { lookupIP = orig }()
+	calls := 0
+	lookupIP = func(string) ([]net.IP, error) {
+		resp := responses[calls]
+		calls++
+		var ips []net.IP
+		for _, s := range resp.ips {
+			ips = append(ips, net.ParseIP(s))
+		}
+		return ips, resp.err
+	}
+
+	ticks := make(chan time.Time, len(responses)-1)
+	for i := 0; i < len(responses)-1; i++ {
+		ticks <- time.Time{}
+	}
+	close(ticks)
+
+	var stdout, stderr, errs bytes.Buffer
+	errorf := func(format string, args ...any) { fmt.Fprintf(&errs, format, args...) }
+	watchHostname("example.com", ticks, &stdout, &stderr, errorf)
+
+	if calls != len(responses) {
+		t.Errorf("resolved %d times, want %d", calls, len(responses))
+	}
+	if want := "192.168.1.0/30\n192.168.1.0/24\n192.168.1.1/32\n"; stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+	for _, want := range []string{
+		"CIDR changed from 192.168.1.0/30 to 192.168.1.0/24: added [192.168.1.200], removed [192.168.1.2]",
+		"CIDR changed from 192.168.1.0/24 to 192.168.1.1/32: added [], removed [192.168.1.200]",
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr = %q, want it to contain %q", stderr.String(), want)
+		}
+	}
+	if want := "Error resolving hostname example.com: temporary failure\n"; errs.String() != want {
+		t.Errorf("errors = %q, want %q", errs.String(), want)
+	}
+	if strings.Contains(stderr.String(), "Error") {
+		t.Errorf("stderr = %q, want the errors to go through errorf only", stderr.String())
+	}
+}